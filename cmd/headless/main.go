@@ -3,6 +3,9 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/nextdotid/proof_server/common"
 	"github.com/nextdotid/proof_server/headless"
@@ -13,12 +16,79 @@ var (
 	flagPort         = flag.Int("port", 9801, "Listen port")
 	flagChromiumPath = flag.String("chromium", "/usr/bin/chromium-browser", "Path to Chromium executable")
 	flagReplace      = flag.String("replace", "", "URL Replacement rule (orig=new,orig2=new2)")
+	flagMaxPages     = flag.Int("max-pages", headless.MaxPages, "Max pages open at the same time")
+	flagQueueSize    = flag.Int("queue-size", headless.QueueSize, "Max requests waiting for a page. Requests beyond it get 429")
+	flagProbe        = flag.Duration("probe-interval", headless.ProbeInterval, "Interval of browser health probe (0 to disable)")
+	flagCaptureDir   = flag.String("capture-dir", "", "Directory to store screenshot and DOM captures. Captures are given inline if empty")
+	flagAuthSecret   = flag.String("auth-secret", os.Getenv("HEADLESS_AUTH_SECRET"), "Secret required from clients as bearer token or HMAC signature (default $HEADLESS_AUTH_SECRET)")
+	flagAllowHosts   = flag.String("allow-hosts", "", "Hosts (and their subdomains) allowed to open, comma-separated. Any host if empty")
+	flagDenyHosts    = flag.String("deny-hosts", "", "Hosts (and their subdomains) denied to open, comma-separated")
+	flagAllowPrivate = flag.Bool("allow-private-ips", false, "Allow opening hosts resolved to private addresses")
+	flagDisableJS    = flag.Bool("disable-js", false, "Reject matches of js type")
+	flagRateLimit    = flag.Float64("rate-limit", 0, "Requests per second allowed for each client IP (0 to disable)")
+	flagRateBurst    = flag.Int("rate-burst", headless.RateBurst, "Burst of requests allowed for each client IP")
+	flagCache        = flag.String("cache", "", "Cache of results, 'memory' or 'disk' (empty to disable)")
+	flagCacheDir     = flag.String("cache-dir", "", "Directory of 'disk' cache")
+	flagCacheTTL     = flag.Duration("cache-ttl", 10*time.Minute, "How long cached results are given")
+	flagProfiles     = flag.String("profiles", "", "Path to JSON file of profiles (cookies, headers, blocked URLs, etc.) keyed by name")
+	flagAllowOrigins = flag.String("allow-origins", "", "Origins allowed by CORS, comma-separated. CORS is disabled if empty")
+	flagProxies      = flag.String("trusted-proxies", "", "Addresses or CIDRs of reverse proxies trusted for X-Forwarded-For, comma-separated. Client IP is the peer address if empty")
 )
 
+func splitList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func main() {
 	flag.Parse()
 	logrus.SetLevel(logrus.DebugLevel)
 	common.CurrentRuntime = common.Runtimes.Standalone
+	headless.MaxPages = *flagMaxPages
+	headless.QueueSize = *flagQueueSize
+	headless.ProbeInterval = *flagProbe
+	if *flagCaptureDir != "" {
+		storage, err := headless.NewDirStorage(*flagCaptureDir)
+		if err != nil {
+			logrus.Fatalf("Error when initializing capture storage: %v", err)
+		}
+		headless.CaptureStorage = storage
+	}
+	headless.AuthSecret = *flagAuthSecret
+	headless.AllowedHosts = splitList(*flagAllowHosts)
+	headless.DeniedHosts = splitList(*flagDenyHosts)
+	headless.AllowPrivateIPs = *flagAllowPrivate
+	headless.DisableJS = *flagDisableJS
+	headless.RateLimit = *flagRateLimit
+	headless.RateBurst = *flagRateBurst
+	headless.AllowedOrigins = splitList(*flagAllowOrigins)
+	headless.TrustedProxies = splitList(*flagProxies)
+	if *flagProfiles != "" {
+		if err := headless.LoadProfiles(*flagProfiles); err != nil {
+			logrus.Fatalf("Error when loading profiles: %v", err)
+		}
+	}
+	switch *flagCache {
+	case "":
+	case "memory":
+		headless.ResultCache = headless.NewMemoryCache(*flagCacheTTL, 0)
+	case "disk":
+		cache, err := headless.NewDiskCache(*flagCacheDir, *flagCacheTTL)
+		if err != nil {
+			logrus.Fatalf("Error when initializing cache: %v", err)
+		}
+		headless.ResultCache = cache
+	default:
+		logrus.Fatalf("Unknown cache: %s", *flagCache)
+	}
+	if headless.AuthSecret == "" {
+		logrus.Warn("No auth secret given. Anyone reaching this service can use it.")
+	}
 	headless.Init(*flagChromiumPath, *flagReplace)
 
 	listen := fmt.Sprintf("0.0.0.0:%d", *flagPort)
@@ -18,13 +18,21 @@ import (
 	"github.com/nextdotid/proof_server/validator/das"
 	"github.com/nextdotid/proof_server/validator/discord"
 	"github.com/nextdotid/proof_server/validator/dns"
+	"github.com/nextdotid/proof_server/validator/email"
 	"github.com/nextdotid/proof_server/validator/ethereum"
+	"github.com/nextdotid/proof_server/validator/gitea"
 	"github.com/nextdotid/proof_server/validator/github"
+	"github.com/nextdotid/proof_server/validator/gitlab"
 	"github.com/nextdotid/proof_server/validator/keybase"
+	"github.com/nextdotid/proof_server/validator/matrix"
 	"github.com/nextdotid/proof_server/validator/minds"
+	"github.com/nextdotid/proof_server/validator/pgp"
+	"github.com/nextdotid/proof_server/validator/reddit"
+	"github.com/nextdotid/proof_server/validator/sns"
 	"github.com/nextdotid/proof_server/validator/solana"
 	"github.com/nextdotid/proof_server/validator/steam"
 	"github.com/nextdotid/proof_server/validator/twitter"
+	"github.com/nextdotid/proof_server/validator/youtube"
 	"github.com/sirupsen/logrus"
 )
 
@@ -53,6 +61,14 @@ func init_validators() {
 	dns.Init()
 	steam.Init()
 	activitypub.Init()
+	gitlab.Init()
+	gitea.Init()
+	pgp.Init()
+	email.Init()
+	reddit.Init()
+	youtube.Init()
+	matrix.Init()
+	sns.Init()
 }
 
 func init() {
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+	"time"
+
 	"github.com/akrylysov/algnhsa"
 	"github.com/nextdotid/proof_server/common"
 	"github.com/nextdotid/proof_server/headless"
@@ -10,6 +13,11 @@ import (
 func init() {
 	logrus.SetLevel(logrus.InfoLevel)
 	common.CurrentRuntime = common.Runtimes.Lambda
+	headless.AuthSecret = os.Getenv("HEADLESS_AUTH_SECRET")
+	// Kept while the function instance is warm.
+	if ttl, err := time.ParseDuration(os.Getenv("HEADLESS_CACHE_TTL")); err == nil {
+		headless.ResultCache = headless.NewMemoryCache(ttl, 0)
+	}
 	headless.Init("/opt/chromium", "")
 }
 
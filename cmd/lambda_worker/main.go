@@ -24,13 +24,21 @@ import (
 	"github.com/nextdotid/proof_server/validator/das"
 	"github.com/nextdotid/proof_server/validator/discord"
 	"github.com/nextdotid/proof_server/validator/dns"
+	"github.com/nextdotid/proof_server/validator/email"
 	"github.com/nextdotid/proof_server/validator/ethereum"
+	"github.com/nextdotid/proof_server/validator/gitea"
 	"github.com/nextdotid/proof_server/validator/github"
+	"github.com/nextdotid/proof_server/validator/gitlab"
 	"github.com/nextdotid/proof_server/validator/keybase"
+	"github.com/nextdotid/proof_server/validator/matrix"
 	"github.com/nextdotid/proof_server/validator/minds"
+	"github.com/nextdotid/proof_server/validator/pgp"
+	"github.com/nextdotid/proof_server/validator/reddit"
+	"github.com/nextdotid/proof_server/validator/sns"
 	"github.com/nextdotid/proof_server/validator/solana"
 	"github.com/nextdotid/proof_server/validator/steam"
 	"github.com/nextdotid/proof_server/validator/twitter"
+	"github.com/nextdotid/proof_server/validator/youtube"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
@@ -192,6 +200,15 @@ func arweave_bundle_single(pc *model.ProofChain, previous *model.ProofChain) (*a
 		PreviousUuid:      previousUuid,
 		PreviousArweaveID: previousArweaveID,
 	}
+	if myconfig.C.Arweave.IncludeEvidence {
+		evidence, err := model.FindEvidenceByProofChain(pc.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("error finding evidence: %w", err)
+		}
+		if evidence != nil {
+			doc.Evidence = evidence.ToArweaveDocument()
+		}
+	}
 
 	json, err := json.MarshalIndent(doc, "", "\t")
 	if err != nil {
@@ -253,6 +270,14 @@ func init_validators() {
 	dns.Init()
 	steam.Init()
 	activitypub.Init()
+	gitlab.Init()
+	gitea.Init()
+	pgp.Init()
+	email.Init()
+	reddit.Init()
+	youtube.Init()
+	matrix.Init()
+	sns.Init()
 }
 
 func init() {
@@ -29,24 +29,72 @@ type DBConfig struct {
 
 type HeadlessConfig struct {
 	Urls []string `json:"urls"`
+	// Capture asks headless service for screenshot and DOM of proof
+	// pages, whose hashes are kept in evidence.
+	Capture bool `json:"capture"`
+	// CaptureDir keeps captures given inline by headless services
+	// without capture storage. Such captures are not recorded if
+	// empty, since nothing would keep them.
+	CaptureDir string `json:"capture_dir"`
+	// Secret is sent to headless services as bearer token if set.
+	Secret string `json:"secret"`
+	// MatchTimeout is how long headless service waits for proof
+	// post to show, e.g. `10s`. Defaults to `5s`.
+	MatchTimeout string `json:"match_timeout"`
+	// RequestTimeout limits each request to headless service.
+	// Defaults to `30s`.
+	RequestTimeout string `json:"request_timeout"`
+	// Retries on the next service when one fails (transport error,
+	// 5xx or busy).
+	Retries int `json:"retries"`
+	// HealthCheckInterval of services, e.g. `30s`. Disabled if
+	// empty; failed services are skipped for a while anyway.
+	HealthCheckInterval string `json:"health_check_interval"`
+	// Profile of headless services to open proof pages with, e.g.
+	// for cookies. Services' `default` profile if empty.
+	Profile string `json:"profile"`
 }
 
 type PlatformConfig struct {
-	Twitter  TwitterPlatformConfig  `json:"twitter"`
-	Telegram TelegramPlatformConfig `json:"telegram"`
-	Ethereum EthereumPlatformConfig `json:"ethereum"`
-	Discord  DiscordPlatformConfig  `json:"discord"`
-	Slack    SlackPlatformConfig    `json:"slack"`
+	Twitter     TwitterPlatformConfig     `json:"twitter"`
+	Github      GithubPlatformConfig      `json:"github"`
+	Telegram    TelegramPlatformConfig    `json:"telegram"`
+	Ethereum    EthereumPlatformConfig    `json:"ethereum"`
+	Discord     DiscordPlatformConfig     `json:"discord"`
+	Slack       SlackPlatformConfig       `json:"slack"`
+	PGP         PGPPlatformConfig         `json:"pgp"`
+	Reddit      RedditPlatformConfig      `json:"reddit"`
+	YouTube     YouTubePlatformConfig     `json:"youtube"`
+	Matrix      MatrixPlatformConfig      `json:"matrix"`
+	ActivityPub ActivityPubPlatformConfig `json:"activitypub"`
+	DNS         DNSPlatformConfig         `json:"dns"`
+	ENS         ENSPlatformConfig         `json:"ens"`
+	SNS         SNSPlatformConfig         `json:"sns"`
 }
 
 type TwitterPlatformConfig struct {
 	// Twitter API v2 Bearer token
 	OauthToken string `json:"oauth_token"`
+	// More bearer tokens to rotate between, along with `OauthToken`.
+	// Rate-limited ones are skipped until reset.
+	OauthTokens []string `json:"oauth_tokens"`
+	// Fetchers to try in order, in `api`, `syndication`, `oembed`
+	// and `headless`. All of them (in this order) if empty.
+	Fetchers []string `json:"fetchers"`
+}
+
+type GithubPlatformConfig struct {
+	// Personal access tokens to rotate between. Rate-limited ones are
+	// skipped until reset. Requests are unauthenticated if empty.
+	Tokens []string `json:"tokens"`
 }
 
 type ArweaveConfig struct {
 	Jwk       string `json:"jwk"`
 	ClientUrl string `json:"client_url"`
+	// IncludeEvidence archives evidence taken when uploading in the
+	// same document of proof chain.
+	IncludeEvidence bool `json:"include_evidence"`
 }
 
 type SqsConfig struct {
@@ -54,21 +102,82 @@ type SqsConfig struct {
 }
 
 type TelegramPlatformConfig struct {
-	ApiID             int    `json:"api_id"`
-	ApiHash           string `json:"api_hash"`
+	ApiID   int    `json:"api_id"`
+	ApiHash string `json:"api_hash"`
+	// BotToken signs in the MTProto session. Not rotated like tokens
+	// of HTTP APIs: requests go through one session per bot.
 	BotToken          string `json:"bot_token"`
 	PublicChannelName string `json:"public_channel_name"`
 }
 
 type SlackPlatformConfig struct {
-	ApiToken        string `json:"api_token"`
-	PublicChannelID string `json:"public_channel_id"`
+	ApiToken string `json:"api_token"`
+	// More tokens to rotate between, along with `ApiToken`.
+	ApiTokens       []string `json:"api_tokens"`
+	PublicChannelID string   `json:"public_channel_id"`
+}
+
+type MatrixPlatformConfig struct {
+	// Client-server API endpoint, e.g. `https://matrix-client.matrix.org`
+	Homeserver  string `json:"homeserver"`
+	AccessToken string `json:"access_token"`
+	// Public room to post proof messages in, e.g. `!abcdef:matrix.org`
+	PublicRoomID string `json:"public_room_id"`
+}
+
+type ActivityPubPlatformConfig struct {
+	// Public URL of application actor document served at `/actor`,
+	// e.g. `https://proof-service.next.id/actor`
+	ActorID string `json:"actor_id"`
+	// PEM-encoded RSA private key of application actor. Object
+	// fetches are signed (HTTP Signatures) with it if given.
+	PrivateKey string `json:"private_key"`
+}
+
+type DNSPlatformConfig struct {
+	// One of `doh_json` (default), `doh` (RFC 8484 wire format), `udp` or `tcp`.
+	Resolver string `json:"resolver"`
+	// DoH endpoint URLs, or `host:port` of resolvers for `udp` / `tcp`.
+	// Tried in order.
+	Servers []string `json:"servers"`
+	// Reject TXT records not validated by DNSSEC (no `AD` flag in answer).
+	RequireDNSSEC bool `json:"require_dnssec"`
+}
+
+type ENSPlatformConfig struct {
+	// Resolve owner of the name when validating, to tell if it is
+	// bound to the same persona with an `ethereum` proof.
+	CheckOwner bool `json:"check_owner"`
+}
+
+type SNSPlatformConfig struct {
+	// Solana JSON-RPC endpoint to read name accounts from.
+	// Defaults to `https://api.mainnet-beta.solana.com`.
+	RPCServer string `json:"rpc_server"`
 }
 
 type EthereumPlatformConfig struct {
 	RPCServer string `json:"rpc_server"`
 }
 
+type PGPPlatformConfig struct {
+	// URL template to fetch armored public key by fingerprint
+	// (`%s` will be replaced by uppercased fingerprint).
+	// Could be a VKS keyserver or a WKD-compatible endpoint.
+	KeyServer string `json:"key_server"`
+}
+
+type RedditPlatformConfig struct {
+	// Base URL to fetch `.json` rendering of permalinks from.
+	// Defaults to `https://www.reddit.com`.
+	BaseURL string `json:"base_url"`
+}
+
+type YouTubePlatformConfig struct {
+	// YouTube Data API v3 key.
+	APIKey string `json:"api_key"`
+}
+
 type CliConfig struct {
 	ServerURL  string `json:"server_url"`
 	UploadPath string `json:"upload_url"`
@@ -76,8 +185,10 @@ type CliConfig struct {
 }
 
 type DiscordPlatformConfig struct {
-	BotToken             string `json:"bot_token"`
-	ProofServerChannelID string `json:"proof_server_channel_id"`
+	BotToken string `json:"bot_token"`
+	// More bot tokens to rotate between, along with `BotToken`.
+	BotTokens            []string `json:"bot_tokens"`
+	ProofServerChannelID string   `json:"proof_server_channel_id"`
 }
 
 var (
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nextdotid/proof_server/validator/activitypub"
+)
+
+// actor serves the ActivityPub application actor document of proof
+// service, so that remote servers can verify signed object fetches.
+func actor(c *gin.Context) {
+	document, err := activitypub.ActorDocument()
+	if err != nil {
+		errorResp(c, http.StatusNotFound, err)
+		return
+	}
+	body, err := json.Marshal(document)
+	if err != nil {
+		errorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/activity+json; charset=utf-8", body)
+}
@@ -33,9 +33,12 @@ func Init() {
 	Engine.Use(middlewareCors())
 
 	Engine.GET("/healthz", healthz)
+	Engine.GET("/actor", actor)
 	Engine.POST("/v1/proof/payload", proofPayload)
 	Engine.POST("/v1/proof", proofUpload)
 	Engine.GET("/v1/proof/exists", proofExists)
+	Engine.GET("/v1/proof/evidence", proofEvidence)
+	Engine.GET("/v1/proof/history", proofHistory)
 	Engine.GET("/v1/proof", proofQuery)
 	Engine.GET("/v1/proofchain/changes", proofChainChanges)
 	Engine.GET("/v1/proofchain", proofChainQuery)
@@ -65,5 +68,7 @@ func healthz(c *gin.Context) {
 		"environment": common.Environment,
 		"revision":    common.Revision,
 		"built_at":    common.BuildTime,
+		"tokens":      validator.TokenPoolHealth(),
+		"headless":    validator.HeadlessHealth(),
 	})
 }
@@ -23,6 +23,9 @@ func before_each(t *testing.T) {
 	// Clean DB
 	model.DB.Where("1 = 1").Delete(&model.Proof{})
 	model.DB.Where("1 = 1").Delete(&model.ProofChain{})
+	model.DB.Where("1 = 1").Delete(&model.IdentityRename{})
+	model.DB.Where("1 = 1").Delete(&model.ProofEvidence{})
+	model.DB.Where("1 = 1").Delete(&model.ProofValidityEvent{})
 }
 
 func TestMain(m *testing.M) {
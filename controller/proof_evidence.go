@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nextdotid/proof_server/model"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+type ProofEvidenceRequest struct {
+	Platform         string `form:"platform"`
+	Identity         string `form:"identity"`
+	PersonaPubkeyHex string `form:"public_key"`
+	Page             int    `form:"page"`
+}
+
+type ProofEvidenceResponse struct {
+	Pagination ProofQueryPaginationResponse  `json:"pagination"`
+	Evidences  []ProofEvidenceResponseSingle `json:"evidences"`
+}
+
+type ProofEvidenceResponseSingle struct {
+	Avatar        string         `json:"avatar"`
+	Platform      types.Platform `json:"platform"`
+	Identity      string         `json:"identity"`
+	ProofLocation string         `json:"proof_location"`
+	// OnUpload is true if taken when the proof was uploaded, false if
+	// taken by revalidation.
+	OnUpload    bool   `json:"on_upload"`
+	ContentHash string `json:"content_hash"`
+	Text        string `json:"text"`
+	Author      string `json:"author"`
+	Fetcher     string `json:"fetcher"`
+	StatusCode  int    `json:"status_code"`
+	FetchedAt   string `json:"fetched_at"`
+	// Cached is true if given from cache of headless service.
+	Cached bool `json:"cached"`
+	// Hashes of page captured by headless browser. Empty if not
+	// captured.
+	ScreenshotHash string `json:"screenshot_hash"`
+	HTMLHash       string `json:"html_hash"`
+	IsValid        bool   `json:"is_valid"`
+	InvalidReason  string `json:"invalid_reason"`
+}
+
+func proofEvidence(c *gin.Context) {
+	req := ProofEvidenceRequest{}
+	if err := c.BindQuery(&req); err != nil {
+		errorResp(c, http.StatusBadRequest, xerrors.Errorf("Param error"))
+		return
+	}
+	if req.Platform == "" || req.Identity == "" {
+		errorResp(c, http.StatusBadRequest, xerrors.Errorf("Param missing"))
+		return
+	}
+
+	pagination := ProofQueryPaginationResponse{
+		Total:   0,
+		Per:     PER_PAGE,
+		Current: req.Page,
+		Next:    0,
+	}
+	if pagination.Current <= 0 {
+		pagination.Current = 1
+	}
+
+	tx := model.ReadOnlyDB.Model(&model.ProofEvidence{}).
+		Where("platform = ? AND identity IN ?", req.Platform, validator.IdentityCandidates(types.Platform(req.Platform), req.Identity))
+	if req.PersonaPubkeyHex != "" {
+		personaPubkey, err := crypto.StringToSecp256k1Pubkey(req.PersonaPubkeyHex)
+		if err != nil {
+			errorResp(c, http.StatusBadRequest, xerrors.Errorf("Public key unmarshal error"))
+			return
+		}
+		tx = tx.Where("persona = ?", model.MarshalAvatar(personaPubkey))
+	}
+
+	countTx := tx
+	if err := countTx.Count(&pagination.Total).Error; err != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("Error in DB: %w", err))
+		return
+	}
+	evidences := make([]model.ProofEvidence, 0)
+	tx = tx.Order("id DESC").Offset(pagination.Per * (pagination.Current - 1)).Limit(pagination.Per).Find(&evidences)
+	if tx.Error != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("Error in DB: %w", tx.Error))
+		return
+	}
+	if pagination.Total > int64(pagination.Per*pagination.Current) {
+		pagination.Next = pagination.Current + 1
+	}
+
+	c.JSON(http.StatusOK, ProofEvidenceResponse{
+		Pagination: pagination,
+		Evidences: lo.Map(evidences, func(evidence model.ProofEvidence, _index int) ProofEvidenceResponseSingle {
+			return ProofEvidenceResponseSingle{
+				Avatar:         evidence.Persona,
+				Platform:       evidence.Platform,
+				Identity:       evidence.Identity,
+				ProofLocation:  evidence.Location,
+				OnUpload:       evidence.ProofID == 0,
+				ContentHash:    evidence.ContentHash,
+				Text:           evidence.Text,
+				Author:         evidence.Author,
+				Fetcher:        evidence.Fetcher,
+				StatusCode:     evidence.StatusCode,
+				FetchedAt:      strconv.FormatInt(evidence.FetchedAt.Unix(), 10),
+				Cached:         evidence.Cached,
+				ScreenshotHash: evidence.ScreenshotHash,
+				HTMLHash:       evidence.HTMLHash,
+				IsValid:        evidence.IsValid,
+				InvalidReason:  evidence.InvalidReason,
+			}
+		}),
+	})
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nextdotid/proof_server/model"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_proofEvidence(t *testing.T) {
+	t.Run("param missing", func(t *testing.T) {
+		before_each(t)
+
+		resp := ErrorResponse{}
+		APITestCall(Engine, "GET", "/v1/proof/evidence?platform=twitter", nil, &resp)
+		require.Contains(t, resp.Message, "Param missing")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		before_each(t)
+
+		pubkey, _ := crypto.StringToSecp256k1Pubkey(persona)
+		base := validator.Base{
+			Platform:      types.Platforms.Twitter,
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      "yeiwb",
+			ProofLocation: "1469221200140574721",
+			CreatedAt:     time.Date(1970, time.Month(1), 1, 0, 0, 0, 0, time.UTC),
+			Signature:     []byte{1},
+			Text:          "Verifying my Twitter ID  \r\nSig: abc\n",
+		}
+		base.RecordEvidence(nil, "yeiwb", "syndication", 200)
+		pc, err := model.ProofChainCreateFromValidator(&base)
+		require.NoError(t, err)
+		require.NoError(t, pc.Apply())
+
+		resp := ProofEvidenceResponse{}
+		APITestCall(Engine, "GET", fmt.Sprintf("/v1/proof/evidence?platform=twitter&identity=YEIWB&public_key=%s", persona), nil, &resp)
+		require.Equal(t, int64(1), resp.Pagination.Total)
+		require.Equal(t, 1, len(resp.Evidences))
+		evidence := resp.Evidences[0]
+		require.True(t, evidence.OnUpload)
+		require.True(t, evidence.IsValid)
+		require.Equal(t, "Verifying my Twitter ID\nSig: abc", evidence.Text)
+		require.Equal(t, "syndication", evidence.Fetcher)
+		require.Equal(t, 200, evidence.StatusCode)
+		require.Len(t, evidence.ContentHash, 64)
+	})
+}
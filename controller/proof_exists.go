@@ -3,11 +3,12 @@ package controller
 import (
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nextdotid/proof_server/model"
+	"github.com/nextdotid/proof_server/types"
 	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
 	"golang.org/x/xerrors"
 )
 
@@ -42,11 +43,11 @@ func proofExists(c *gin.Context) {
 	}
 	found := model.Proof{}
 	tx := model.ReadOnlyDB.Where(
-		"persona = ? AND platform = ? AND (identity = ? OR alt_id = ?)",
+		"persona = ? AND platform = ? AND (identity IN ? OR alt_id IN ?)",
 		model.MarshalAvatar(personaPubkey),
 		req.Platform,
-		strings.ToLower(req.Identity),
-		strings.ToLower(req.Identity),
+		validator.IdentityCandidates(types.Platform(req.Platform), req.Identity),
+		validator.IdentityCandidates(types.Platform(req.Platform), req.Identity),
 	).Find(&found)
 
 	if tx.Error != nil {
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nextdotid/proof_server/model"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/samber/lo"
+	"golang.org/x/xerrors"
+)
+
+type ProofHistoryRequest struct {
+	Persona  string `form:"persona"`
+	Platform string `form:"platform"`
+	Identity string `form:"identity"`
+}
+
+type ProofHistoryResponse struct {
+	Platform  types.Platform              `json:"platform"`
+	Identity  string                      `json:"identity"`
+	CreatedAt string                      `json:"created_at"`
+	Uptime    ProofUptimeResponse         `json:"uptime"`
+	Events    []ProofHistoryResponseEvent `json:"events"`
+}
+
+type ProofHistoryResponseEvent struct {
+	Identity      string `json:"identity"`
+	IsValid       bool   `json:"is_valid"`
+	InvalidReason string `json:"invalid_reason"`
+	CheckedAt     string `json:"checked_at"`
+}
+
+type ProofUptimeResponse struct {
+	Checks      int     `json:"checks"`
+	ValidChecks int     `json:"valid_checks"`
+	Ratio       float64 `json:"ratio"`
+	Flaps       int     `json:"flaps"`
+	// LastChangedAt is "0" if validity never changed.
+	LastChangedAt string `json:"last_changed_at"`
+}
+
+func proofHistory(c *gin.Context) {
+	req := ProofHistoryRequest{}
+	if err := c.BindQuery(&req); err != nil {
+		errorResp(c, http.StatusBadRequest, xerrors.Errorf("Param error"))
+		return
+	}
+	if req.Persona == "" || req.Platform == "" || req.Identity == "" {
+		errorResp(c, http.StatusBadRequest, xerrors.Errorf("Param missing"))
+		return
+	}
+	personaPubkey, err := crypto.StringToSecp256k1Pubkey(req.Persona)
+	if err != nil {
+		errorResp(c, http.StatusBadRequest, xerrors.Errorf("Public key unmarshal error"))
+		return
+	}
+
+	found := model.Proof{}
+	tx := model.ReadOnlyDB.Where(
+		"persona = ? AND platform = ? AND (identity IN ? OR alt_id IN ?)",
+		model.MarshalAvatar(personaPubkey),
+		req.Platform,
+		validator.IdentityCandidates(types.Platform(req.Platform), req.Identity),
+		validator.IdentityCandidates(types.Platform(req.Platform), req.Identity),
+	).Find(&found)
+	if tx.Error != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("Error in DB: %w", tx.Error))
+		return
+	}
+	if found.ID == int64(0) {
+		errorResp(c, http.StatusNotFound, xerrors.Errorf("Record not found for %s: %s", req.Platform, req.Identity))
+		return
+	}
+
+	events, err := model.FindAllValidityEvents(found.ID)
+	if err != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
+		return
+	}
+	uptimes, err := model.SummarizeUptime([]model.Proof{found})
+	if err != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, ProofHistoryResponse{
+		Platform:  found.Platform,
+		Identity:  found.Identity,
+		CreatedAt: strconv.FormatInt(found.CreatedAt.Unix(), 10),
+		Uptime:    toProofUptimeResponse(uptimes[found.ID]),
+		Events: lo.Map(events, func(event model.ProofValidityEvent, _index int) ProofHistoryResponseEvent {
+			return ProofHistoryResponseEvent{
+				Identity:      event.Identity,
+				IsValid:       event.IsValid,
+				InvalidReason: event.InvalidReason,
+				CheckedAt:     strconv.FormatInt(event.CheckedAt.Unix(), 10),
+			}
+		}),
+	})
+}
+
+func toProofUptimeResponse(uptime model.ProofUptime) ProofUptimeResponse {
+	lastChangedAt := "0"
+	if !uptime.LastChangedAt.IsZero() {
+		lastChangedAt = strconv.FormatInt(uptime.LastChangedAt.Unix(), 10)
+	}
+	return ProofUptimeResponse{
+		Checks:        uptime.Checks,
+		ValidChecks:   uptime.ValidChecks,
+		Ratio:         uptime.Ratio,
+		Flaps:         uptime.Flaps,
+		LastChangedAt: lastChangedAt,
+	}
+}
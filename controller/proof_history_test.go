@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nextdotid/proof_server/model"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_proofHistory(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		before_each(t)
+
+		resp := ErrorResponse{}
+		APITestCall(Engine, "GET", fmt.Sprintf("/v1/proof/history?persona=%s&platform=twitter&identity=yeiwb", persona), nil, &resp)
+		require.Contains(t, resp.Message, "not found")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		before_each(t)
+		insert_proof(t)
+
+		proof := model.Proof{}
+		require.NoError(t, model.DB.Where("platform = ?", types.Platforms.Twitter).Take(&proof).Error)
+		for i, valid := range []bool{true, false, true} {
+			require.NoError(t, model.DB.Create(&model.ProofValidityEvent{
+				ProofID:   proof.ID,
+				Persona:   proof.Persona,
+				Platform:  proof.Platform,
+				Identity:  proof.Identity,
+				IsValid:   valid,
+				CheckedAt: proof.CreatedAt.Add(time.Duration(i+1) * time.Second),
+			}).Error)
+		}
+
+		resp := ProofHistoryResponse{}
+		APITestCall(Engine, "GET", fmt.Sprintf("/v1/proof/history?persona=%s&platform=twitter&identity=yeiwb", persona), nil, &resp)
+		require.Equal(t, 3, len(resp.Events))
+		require.False(t, resp.Events[1].IsValid)
+		require.Equal(t, 3, resp.Uptime.Checks)
+		require.Equal(t, 2, resp.Uptime.ValidChecks)
+		require.Equal(t, 2, resp.Uptime.Flaps)
+
+		query := ProofQueryResponse{}
+		APITestCall(Engine, "GET", "/v1/proof?platform=twitter&identity=yeiwb", "", &query)
+		require.Equal(t, 1, len(query.IDs))
+		for _, p := range query.IDs[0].Proofs {
+			if p.Platform == types.Platforms.Twitter {
+				require.Equal(t, 3, p.Uptime.Checks)
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/nextdotid/proof_server/model"
 	"github.com/nextdotid/proof_server/types"
 	"github.com/nextdotid/proof_server/util/sqs"
+	"github.com/nextdotid/proof_server/validator"
 	"github.com/samber/lo"
 	"golang.org/x/xerrors"
 )
@@ -56,6 +58,12 @@ type ProofQueryResponseSingleProof struct {
 	LastCheckedAt string         `json:"last_checked_at"`
 	IsValid       bool           `json:"is_valid"`
 	InvalidReason string         `json:"invalid_reason"`
+	VerifiedOwner bool           `json:"verified_owner"`
+	// PreviousIdentities are names of this account before renamed,
+	// oldest first. Searching by them gives this proof too.
+	PreviousIdentities []string `json:"previous_identities"`
+	// Uptime summarizes revalidation history. See `GET /v1/proof/history`.
+	Uptime ProofUptimeResponse `json:"uptime"`
 }
 
 func proofQuery(c *gin.Context) {
@@ -129,9 +137,9 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 	case "":
 		{ // All platform
 			if req.ExactMatch {
-				tx = tx.Where("proof.identity = ? OR proof.alt_id = ?", strings.ToLower(req.Identity[0]), strings.ToLower(req.Identity[0]))
+				tx = tx.Where(identityCondition(req.Platform, req.Identity[0], true))
 			} else {
-				tx = tx.Where("proof.identity LIKE ? OR proof.alt_id LIKE ?", "%"+strings.ToLower(req.Identity[0])+"%", "%"+strings.ToLower(req.Identity[0])+"%")
+				tx = tx.Where(identityCondition(req.Platform, req.Identity[0], false))
 			}
 
 			for i, id := range req.Identity {
@@ -139,9 +147,9 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 					continue
 				}
 				if req.ExactMatch {
-					tx = tx.Or("proof.identity = ? OR proof.alt_id = ?", strings.ToLower(id), strings.ToLower(id))
+					tx = tx.Or(identityCondition(req.Platform, id, true))
 				} else {
-					tx = tx.Or("proof.identity LIKE ? OR proof.alt_id LIKE ?", "%"+strings.ToLower(id)+"%", "%"+strings.ToLower(id)+"%")
+					tx = tx.Or(identityCondition(req.Platform, id, false))
 				}
 			}
 			countTx := tx // Value-copy another query for total amount calculation
@@ -152,9 +160,9 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 		{
 			tx = tx.Where("proof.platform", req.Platform)
 			if req.ExactMatch {
-				tx = tx.Where("proof.identity = ? OR proof.alt_id = ?", strings.ToLower(req.Identity[0]), strings.ToLower(req.Identity[0]))
+				tx = tx.Where(identityCondition(req.Platform, req.Identity[0], true))
 			} else {
-				tx = tx.Where("proof.identity LIKE ? OR proof.alt_id LIKE ?", "%"+strings.ToLower(req.Identity[0])+"%", "%"+strings.ToLower(req.Identity[0])+"%")
+				tx = tx.Where(identityCondition(req.Platform, req.Identity[0], false))
 			}
 
 			for i, id := range req.Identity {
@@ -163,9 +171,9 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 				}
 
 				if req.ExactMatch {
-					tx = tx.Or("proof.identity = ? OR proof.alt_id = ?", strings.ToLower(id), strings.ToLower(id))
+					tx = tx.Or(identityCondition(req.Platform, id, true))
 				} else {
-					tx = tx.Or("proof.identity LIKE ? OR proof.alt_id LIKE ?", "%"+strings.ToLower(id)+"%", "%"+strings.ToLower(id)+"%")
+					tx = tx.Or(identityCondition(req.Platform, id, false))
 				}
 			}
 			countTx := tx
@@ -207,6 +215,19 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 			aliases = make([]string, 0, 0)
 		}
 
+		previousIdentities, err := model.FindPreviousIdentities(lo.Map(proofs, func(p model.Proof, _index int) int64 {
+			return p.ID
+		}))
+		if err != nil {
+			l.Warnf("Error when fetching previous identities for %s: %s", persona, err.Error())
+			previousIdentities = map[int64][]string{}
+		}
+		uptimes, err := model.SummarizeUptime(proofs)
+		if err != nil {
+			l.Warnf("Error when summarizing uptime for %s: %s", persona, err.Error())
+			uptimes = map[int64]model.ProofUptime{}
+		}
+
 		single := ProofQueryResponseSingle{
 			Persona:     persona,
 			Avatar:      persona,
@@ -221,6 +242,13 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 					LastCheckedAt: strconv.FormatInt(proof.LastCheckedAt.Unix(), 10),
 					IsValid:       proof.IsValid,
 					InvalidReason: proof.InvalidReason,
+					VerifiedOwner: proof.VerifiedOwner,
+					PreviousIdentities: lo.Ternary(
+						previousIdentities[proof.ID] != nil,
+						previousIdentities[proof.ID],
+						[]string{},
+					),
+					Uptime: toProofUptimeResponse(uptimes[proof.ID]),
 				}
 			}),
 		}
@@ -243,6 +271,19 @@ func performProofQuery(req ProofQueryRequest) ([]ProofQueryResponseSingle, Proof
 	return result, pagination
 }
 
+// identityCondition matches proofs by identity, alt ID, or identity
+// before renamed. Exact match is done with candidates given by
+// `validator.IdentityCandidates`, since some identities (e.g. YouTube
+// channel ID) are stored in their original case.
+func identityCondition(platform, identity string, exact bool) (query string, arg sql.NamedArg) {
+	if exact {
+		return "proof.identity IN @identities OR proof.alt_id IN @identities OR proof.id IN (SELECT proof_id FROM identity_rename WHERE old_identity IN @identities)",
+			sql.Named("identities", validator.IdentityCandidates(types.Platform(platform), identity))
+	}
+	return "proof.identity LIKE @identity OR proof.alt_id LIKE @identity OR proof.id IN (SELECT proof_id FROM identity_rename WHERE old_identity LIKE @identity)",
+		sql.Named("identity", "%"+strings.ToLower(identity)+"%")
+}
+
 func triggerRevalidate(proofID int64) error {
 	switch common.CurrentRuntime {
 	case common.Runtimes.Standalone:
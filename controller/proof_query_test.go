@@ -240,6 +240,51 @@ func Test_proofQuery(t *testing.T) {
 		require.Equal(t, 1, len(resp.IDs))
 	})
 
+	t.Run("previous identity", func(t *testing.T) {
+		before_each(t)
+		insert_proof(t)
+
+		proof := model.Proof{}
+		require.NoError(t, model.DB.Where("platform = ?", types.Platforms.Twitter).Take(&proof).Error)
+		require.NoError(t, model.DB.Create(&model.IdentityRename{
+			ProofID:     proof.ID,
+			Persona:     proof.Persona,
+			Platform:    proof.Platform,
+			AltID:       "1468853291941773312",
+			OldIdentity: "yeiwb",
+			NewIdentity: "yeiwb_new",
+		}).Error)
+		require.NoError(t, model.DB.Model(&proof).Update("identity", "yeiwb_new").Error)
+
+		for _, identity := range []string{"yeiwb", "yeiwb_new"} {
+			resp := ProofQueryResponse{}
+			APITestCall(Engine, "GET", "/v1/proof?platform=twitter&exact=true&identity="+identity, "", &resp)
+			require.Equal(t, 1, len(resp.IDs))
+			twitterProof, _ := lo.Find(resp.IDs[0].Proofs, func(p ProofQueryResponseSingleProof) bool {
+				return p.Platform == types.Platforms.Twitter
+			})
+			require.Equal(t, "yeiwb_new", twitterProof.Identity)
+			require.Equal(t, []string{"yeiwb"}, twitterProof.PreviousIdentities)
+		}
+	})
+
+	t.Run("identity stored in original case", func(t *testing.T) {
+		before_each(t)
+		insert_proof(t)
+
+		proof := model.Proof{}
+		require.NoError(t, model.DB.Where("platform = ?", types.Platforms.Twitter).Take(&proof).Error)
+		require.NoError(t, model.DB.Model(&proof).Update("identity", "UCxyzABC").Error)
+
+		resp := ProofQueryResponse{}
+		APITestCall(Engine, "GET", "/v1/proof?exact=true&identity=UCxyzABC", "", &resp)
+		require.Equal(t, 1, len(resp.IDs))
+
+		resp = ProofQueryResponse{}
+		APITestCall(Engine, "GET", "/v1/proof?exact=true&identity=ucxyzabc", "", &resp)
+		require.Equal(t, 0, len(resp.IDs), "case-sensitive identities do not collide")
+	})
+
 	t.Run("sort", func(t *testing.T) {
 		before_each(t)
 		insert_proof(t)
@@ -3,6 +3,7 @@ package controller
 import (
 	"crypto/ecdsa"
 	"encoding/base64"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,24 @@ type ProofUploadRequest struct {
 type ProofUploadRequestExtra struct {
 	Signature               string `json:"signature"`
 	EthereumWalletSignature string `json:"wallet_signature"`
+	PGPSignedMessage        string `json:"pgp_signed_message"`
+	PGPPublicKey            string `json:"pgp_public_key"`
+	EmailRawMessage         string `json:"email_raw_message"`
+}
+
+// redacted replaces raw messages and key material with their length,
+// to keep them out of logs.
+func (extra ProofUploadRequestExtra) redacted() ProofUploadRequestExtra {
+	redact := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return fmt.Sprintf("<redacted, %d bytes>", len(value))
+	}
+	extra.PGPSignedMessage = redact(extra.PGPSignedMessage)
+	extra.PGPPublicKey = redact(extra.PGPPublicKey)
+	extra.EmailRawMessage = redact(extra.EmailRawMessage)
+	return extra
 }
 
 func proofUpload(c *gin.Context) {
@@ -40,7 +59,9 @@ func proofUpload(c *gin.Context) {
 		return
 	}
 
-	l.Infof("UPLOADING PROOF: %+v", req)
+	logged := req
+	logged.Extra = req.Extra.redacted()
+	l.Infof("UPLOADING PROOF: %+v", logged)
 	pubkey, err := mycrypto.StringToSecp256k1Pubkey(req.PublicKey)
 	if err != nil {
 		errorResp(c, 400, xerrors.Errorf("%w", err))
@@ -112,6 +133,20 @@ func validateProof(req ProofUploadRequest, prev *model.ProofChain, pubkey *ecdsa
 		}
 		base.Signature = persona_sig
 	}
+	// Platform-specific proof content given in `extra`.
+	for key, value := range map[string]string{
+		"pgp_signed_message": req.Extra.PGPSignedMessage,
+		"pgp_public_key":     req.Extra.PGPPublicKey,
+		"email_raw_message":  req.Extra.EmailRawMessage,
+	} {
+		if value == "" {
+			continue
+		}
+		if base.Extra == nil {
+			base.Extra = map[string]string{}
+		}
+		base.Extra[key] = value
+	}
 
 	performer := performer_factory(&base)
 	return base, performer.Validate()
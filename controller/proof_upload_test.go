@@ -49,3 +49,15 @@ func Test_ProofUpload(t *testing.T) {
 		assert.Equal(t, req.PublicKey, proof.Persona)
 	})
 }
+
+func Test_ProofUploadRequestExtra_redacted(t *testing.T) {
+	extra := ProofUploadRequestExtra{
+		Signature:       "c2ln",
+		EmailRawMessage: "From: alice@example.com\r\n\r\nsecret",
+	}
+	redacted := extra.redacted()
+	assert.Equal(t, "c2ln", redacted.Signature)
+	assert.Equal(t, "<redacted, 33 bytes>", redacted.EmailRawMessage)
+	assert.Equal(t, "", redacted.PGPPublicKey)
+	assert.Contains(t, extra.EmailRawMessage, "secret", "original is kept")
+}
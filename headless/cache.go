@@ -0,0 +1,212 @@
+package headless
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultCacheTTL        = 10 * time.Minute
+	defaultCacheMaxEntries = 1024
+)
+
+var (
+	// ResultCache keeps successful results if set.
+	ResultCache Cache
+)
+
+// Cache keeps results of find requests by `CacheKey`. Results
+// expired are not given.
+type Cache interface {
+	Get(key string) (*FindRespond, bool)
+	Set(key string, resp *FindRespond)
+}
+
+// CacheKey gives the key of `req` to open `location`, by normalized
+// location and everything else affecting result but timeout.
+func CacheKey(location string, req *FindRequest) string {
+	spec, _ := json.Marshal(struct {
+		Match       Match            `json:"match"`
+		Extractions map[string]Match `json:"extractions"`
+		WaitXHR     bool             `json:"wait_xhr"`
+		Capture     *CaptureRequest  `json:"capture"`
+		Profile     string           `json:"profile"`
+	}{req.Match, req.Extractions, req.WaitXHR, req.Capture, req.Profile})
+
+	sum := sha256.New()
+	sum.Write([]byte(normalizeLocation(location)))
+	sum.Write([]byte("\n"))
+	sum.Write(spec)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// normalizeLocation lowercases scheme and host, removes default port,
+// fragment and trailing slash, and sorts query.
+func normalizeLocation(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+// cacheable tells if `resp` is a complete result.
+func cacheable(resp *FindRespond) bool {
+	return resp.Message == "" && len(resp.Errors) == 0
+}
+
+// cacheEntry gives a copy of `resp` to cache, without inline capture
+// content. Cached captures carry hashes only: their content was given
+// when the page was rendered.
+func cacheEntry(resp *FindRespond) *FindRespond {
+	entry := *resp
+	if resp.Capture != nil {
+		captured := *resp.Capture
+		captured.Screenshot, captured.HTML = "", ""
+		entry.Capture = &captured
+	}
+	return &entry
+}
+
+// MemoryCache keeps results in memory, up to `maxEntries`.
+type MemoryCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*FindRespond
+	now        func() time.Time
+}
+
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &MemoryCache{ttl: ttl, maxEntries: maxEntries, entries: map[string]*FindRespond{}, now: time.Now}
+}
+
+func (cache *MemoryCache) Get(key string) (*FindRespond, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	resp, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if cache.now().Sub(resp.FetchedAt) > cache.ttl {
+		delete(cache.entries, key)
+		return nil, false
+	}
+	copied := *resp
+	return &copied, true
+}
+
+func (cache *MemoryCache) Set(key string, resp *FindRespond) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, ok := cache.entries[key]; !ok && len(cache.entries) >= cache.maxEntries {
+		cache.evict()
+	}
+	copied := *resp
+	cache.entries[key] = &copied
+}
+
+// evict removes expired entries, or the oldest one if none expired.
+func (cache *MemoryCache) evict() {
+	now := cache.now()
+	oldestKey := ""
+	var oldest time.Time
+	for key, resp := range cache.entries {
+		if now.Sub(resp.FetchedAt) > cache.ttl {
+			delete(cache.entries, key)
+			continue
+		}
+		if oldestKey == "" || resp.FetchedAt.Before(oldest) {
+			oldestKey, oldest = key, resp.FetchedAt
+		}
+	}
+	if len(cache.entries) >= cache.maxEntries {
+		delete(cache.entries, oldestKey)
+	}
+}
+
+// DiskCache keeps results as JSON files named `<key>.json` under a
+// directory, so they survive restarts and can be shared.
+type DiskCache struct {
+	Dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, xerrors.Errorf("creating cache directory: %w", err)
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &DiskCache{Dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+func (cache *DiskCache) Get(key string) (*FindRespond, bool) {
+	path := filepath.Join(cache.Dir, key+".json")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	resp := &FindRespond{}
+	if err := json.Unmarshal(content, resp); err != nil || cache.now().Sub(resp.FetchedAt) > cache.ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return resp, true
+}
+
+func (cache *DiskCache) Set(key string, resp *FindRespond) {
+	content, err := json.Marshal(resp)
+	if err != nil {
+		l.Warnf("caching result: %s", err.Error())
+		return
+	}
+	// Same key may be written concurrently.
+	tmp, err := os.CreateTemp(cache.Dir, key+".*.tmp")
+	if err != nil {
+		l.Warnf("caching result: %s", err.Error())
+		return
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(cache.Dir, key+".json"))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		l.Warnf("caching result: %s", err.Error())
+	}
+}
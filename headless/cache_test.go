@@ -0,0 +1,81 @@
+package headless
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CacheKey(t *testing.T) {
+	req := &FindRequest{Match: Match{Type: matchTypeRegex, MatchRegExp: &MatchRegExp{Selector: "*", Value: "Sig"}}}
+	key := CacheKey("https://x.com/yeiwb/status/1?b=2&a=1", req)
+
+	require.Equal(t, key, CacheKey("HTTPS://X.com:443/yeiwb/status/1/?a=1&b=2#top", req), "normalized location")
+
+	withTimeout := *req
+	withTimeout.Timeout = "30s"
+	require.Equal(t, key, CacheKey("https://x.com/yeiwb/status/1?b=2&a=1", &withTimeout), "timeout is not in key")
+
+	other := *req
+	other.Match = Match{Type: matchTypeRegex, MatchRegExp: &MatchRegExp{Selector: "*", Value: "Other"}}
+	require.NotEqual(t, key, CacheKey("https://x.com/yeiwb/status/1?b=2&a=1", &other))
+	require.NotEqual(t, key, CacheKey("https://x.com/yeiwb/status/2?b=2&a=1", req))
+}
+
+func Test_MemoryCache(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", &FindRespond{Content: "a", FetchedAt: now.Add(-2 * time.Minute)})
+	_, ok := cache.Get("a")
+	require.False(t, ok, "expired")
+
+	cache.Set("b", &FindRespond{Content: "b", FetchedAt: now.Add(-time.Second)})
+	cache.Set("c", &FindRespond{Content: "c", FetchedAt: now})
+	cache.Set("d", &FindRespond{Content: "d", FetchedAt: now})
+	_, ok = cache.Get("b")
+	require.False(t, ok, "oldest evicted")
+
+	resp, ok := cache.Get("c")
+	require.True(t, ok)
+	require.Equal(t, "c", resp.Content)
+	resp.Cached = true
+	resp, _ = cache.Get("c")
+	require.False(t, resp.Cached, "entry is not changed by caller")
+}
+
+func Test_DiskCache(t *testing.T) {
+	now := time.Now()
+	cache, err := NewDiskCache(t.TempDir(), time.Minute)
+	require.NoError(t, err)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", &FindRespond{Content: "Sig: abc", Results: map[string][]string{"match": {"Sig: abc"}}, FetchedAt: now})
+	resp, ok := cache.Get("a")
+	require.True(t, ok)
+	require.Equal(t, "Sig: abc", resp.Content)
+	require.Equal(t, []string{"Sig: abc"}, resp.Results["match"])
+	require.True(t, now.Equal(resp.FetchedAt))
+
+	cache.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, ok = cache.Get("a")
+	require.False(t, ok, "expired")
+	_, ok = cache.Get("missing")
+	require.False(t, ok)
+}
+
+func Test_cacheable(t *testing.T) {
+	require.True(t, cacheable(&FindRespond{Content: "Sig: abc"}))
+	require.False(t, cacheable(&FindRespond{Message: "timeout"}))
+	require.False(t, cacheable(&FindRespond{Content: "Sig: abc", Errors: map[string]string{"capture": "failed"}}))
+}
+
+func Test_cacheEntry(t *testing.T) {
+	resp := &FindRespond{Content: "Sig: abc", Capture: &Capture{ScreenshotHash: "a", Screenshot: "cG5n", HTMLHash: "b", HTML: "aHRtbA=="}}
+	entry := cacheEntry(resp)
+	require.Equal(t, &Capture{ScreenshotHash: "a", HTMLHash: "b"}, entry.Capture)
+	require.Equal(t, "cG5n", resp.Capture.Screenshot, "response is not changed")
+	require.Nil(t, cacheEntry(&FindRespond{}).Capture)
+}
@@ -0,0 +1,156 @@
+package headless
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"golang.org/x/xerrors"
+)
+
+var (
+	// CaptureStorage keeps captured content if set. Otherwise captures
+	// are always given inline.
+	CaptureStorage Storage
+)
+
+type CaptureRequest struct {
+	// Screenshot captures a full-page PNG.
+	Screenshot bool `json:"screenshot"`
+	// HTML captures serialized DOM.
+	HTML bool `json:"html"`
+	// Inline gives captured content in response even if
+	// `CaptureStorage` is set.
+	Inline bool `json:"inline"`
+}
+
+// Capture is the result of `CaptureRequest`. Hashes are hex-encoded
+// SHA256 of the content, which is also the key in `CaptureStorage`.
+type Capture struct {
+	ScreenshotHash string `json:"screenshot_hash,omitempty"`
+	// Screenshot is base64-encoded PNG, if given inline.
+	Screenshot string `json:"screenshot,omitempty"`
+	HTMLHash   string `json:"html_hash,omitempty"`
+	// HTML is base64-encoded, if given inline.
+	HTML string `json:"html,omitempty"`
+	// Stored is true if written to `CaptureStorage`.
+	Stored bool `json:"stored"`
+}
+
+// Storage keeps captured content by its hash.
+type Storage interface {
+	Put(hash, ext string, content []byte) error
+}
+
+// DirStorage writes captures to files named `<hash>.<ext>` under a
+// directory.
+type DirStorage struct {
+	Dir string
+}
+
+func NewDirStorage(dir string) (*DirStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, xerrors.Errorf("creating capture directory: %w", err)
+	}
+	return &DirStorage{Dir: dir}, nil
+}
+
+func (s *DirStorage) Put(hash, ext string, content []byte) error {
+	path := filepath.Join(s.Dir, hash+"."+ext)
+	// Content-addressed. Same hash, same content.
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return xerrors.Errorf("writing capture: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Has tells if content of `hash` is kept.
+func (s *DirStorage) Has(hash, ext string) bool {
+	_, err := os.Stat(filepath.Join(s.Dir, hash+"."+ext))
+	return err == nil
+}
+
+// StoreInline writes inline content of `captured` to `storage`, after
+// checking it against the hashes. Content is dropped from `captured`
+// then.
+func (captured *Capture) StoreInline(storage Storage) error {
+	for _, item := range []struct {
+		hash, ext string
+		content   *string
+	}{
+		{captured.ScreenshotHash, "png", &captured.Screenshot},
+		{captured.HTMLHash, "html", &captured.HTML},
+	} {
+		if *item.content == "" {
+			continue
+		}
+		content, err := base64.StdEncoding.DecodeString(*item.content)
+		if err != nil {
+			return xerrors.Errorf("decoding %s capture: %w", item.ext, err)
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != item.hash {
+			return xerrors.Errorf("%s capture does not match its hash", item.ext)
+		}
+		if err := storage.Put(item.hash, item.ext, content); err != nil {
+			return xerrors.Errorf("storing capture: %w", err)
+		}
+		*item.content = ""
+	}
+	return nil
+}
+
+func capture(req *CaptureRequest, page *rod.Page) (*Capture, error) {
+	result := &Capture{}
+	inline := req.Inline || CaptureStorage == nil
+	result.Stored = !inline
+
+	if req.Screenshot {
+		png, err := page.Screenshot(true, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+		if err != nil {
+			return nil, xerrors.Errorf("taking screenshot: %w", err)
+		}
+		if result.ScreenshotHash, err = keep(png, "png", inline); err != nil {
+			return nil, err
+		}
+		if inline {
+			result.Screenshot = base64.StdEncoding.EncodeToString(png)
+		}
+	}
+
+	if req.HTML {
+		html, err := page.HTML()
+		if err != nil {
+			return nil, xerrors.Errorf("serializing DOM: %w", err)
+		}
+		if result.HTMLHash, err = keep([]byte(html), "html", inline); err != nil {
+			return nil, err
+		}
+		if inline {
+			result.HTML = base64.StdEncoding.EncodeToString([]byte(html))
+		}
+	}
+
+	return result, nil
+}
+
+// keep hashes `content`, and writes it to `CaptureStorage` if not
+// `inline`.
+func keep(content []byte, ext string, inline bool) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	if !inline {
+		if err := CaptureStorage.Put(hash, ext, content); err != nil {
+			return "", xerrors.Errorf("storing capture: %w", err)
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,55 @@
+package headless
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keep(t *testing.T) {
+	original := CaptureStorage
+	t.Cleanup(func() { CaptureStorage = original })
+
+	storage, err := NewDirStorage(filepath.Join(t.TempDir(), "captures"))
+	require.NoError(t, err)
+	CaptureStorage = storage
+
+	// sha256("Sig: abc")
+	const hash = "bcf047f8e6144e8c1e1243f77b62dfd227f0841cfc85ee0995f011b0e9da7356"
+
+	got, err := keep([]byte("Sig: abc"), "html", true)
+	require.NoError(t, err)
+	require.Equal(t, hash, got)
+	_, err = os.Stat(filepath.Join(storage.Dir, hash+".html"))
+	require.True(t, os.IsNotExist(err), "inline content is not stored")
+
+	got, err = keep([]byte("Sig: abc"), "html", false)
+	require.NoError(t, err)
+	require.Equal(t, hash, got)
+	content, err := os.ReadFile(filepath.Join(storage.Dir, hash+".html"))
+	require.NoError(t, err)
+	require.Equal(t, "Sig: abc", string(content))
+
+	// Stored again without error.
+	_, err = keep([]byte("Sig: abc"), "html", false)
+	require.NoError(t, err)
+}
+
+func Test_Capture_StoreInline(t *testing.T) {
+	storage, err := NewDirStorage(t.TempDir())
+	require.NoError(t, err)
+	// sha256("Sig: abc")
+	const hash = "bcf047f8e6144e8c1e1243f77b62dfd227f0841cfc85ee0995f011b0e9da7356"
+
+	captured := &Capture{HTMLHash: hash, HTML: base64.StdEncoding.EncodeToString([]byte("Sig: abc"))}
+	require.NoError(t, captured.StoreInline(storage))
+	require.Empty(t, captured.HTML, "content is dropped")
+	require.True(t, storage.Has(hash, "html"))
+	require.False(t, storage.Has(hash, "png"))
+
+	tampered := &Capture{HTMLHash: hash, HTML: base64.StdEncoding.EncodeToString([]byte("Sig: def"))}
+	require.ErrorContains(t, tampered.StoreInline(storage), "does not match")
+}
@@ -8,65 +8,307 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
+	"time"
 
 	"golang.org/x/xerrors"
 )
 
-// HeadlessClient handles communication for headless browser service
+const (
+	DefaultMatchTimeout   = "5s"
+	defaultRequestTimeout = 30 * time.Second
+	defaultBackoff        = 500 * time.Millisecond
+	defaultCooldown       = 30 * time.Second
+)
+
+var (
+	ErrNoEndpoint = xerrors.New("no headless service configured")
+)
+
+// ClientOptions tunes `HeadlessClient`. Zero values give defaults.
+type ClientOptions struct {
+	// Secret is sent as bearer token if not empty.
+	Secret string
+	// Timeout of each attempt.
+	Timeout time.Duration
+	// Retries after the first attempt, on transport error, 5xx or
+	// 429 (page pool busy). Each retry goes to the next endpoint.
+	Retries int
+	// Backoff before first retry. Doubled for each retry after.
+	Backoff time.Duration
+	// Cooldown is how long a failed endpoint is skipped.
+	Cooldown time.Duration
+}
+
+// HeadlessClient handles communication for headless browser service.
+// Requests are sent round-robin to endpoints not failed recently.
 type HeadlessClient struct {
-	url    string
-	client *http.Client
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+	now       func() time.Time
+
+	client   *http.Client
+	secret   string
+	retries  int
+	backoff  time.Duration
+	cooldown time.Duration
+}
+
+type endpoint struct {
+	url       string
+	downUntil time.Time
+	successes int64
+	failures  int64
+	lastError string
+}
+
+// EndpointHealth is the status of one headless service.
+type EndpointHealth struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	DownUntil time.Time `json:"down_until,omitempty"`
+	Successes int64     `json:"successes"`
+	Failures  int64     `json:"failures"`
+	LastError string    `json:"last_error,omitempty"`
 }
 
 // NewHeadlessClient creates a new headless client
-func NewHeadlessClient(url string) *HeadlessClient {
-	return &HeadlessClient{url, http.DefaultClient}
+func NewHeadlessClient(urls []string, options ClientOptions) *HeadlessClient {
+	h := &HeadlessClient{
+		now:      time.Now,
+		secret:   options.Secret,
+		retries:  options.Retries,
+		backoff:  options.Backoff,
+		cooldown: options.Cooldown,
+	}
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		h.endpoints = append(h.endpoints, &endpoint{url: u})
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	h.client = &http.Client{Timeout: timeout}
+	if h.retries < 0 {
+		h.retries = 0
+	}
+	if h.backoff <= 0 {
+		h.backoff = defaultBackoff
+	}
+	if h.cooldown <= 0 {
+		h.cooldown = defaultCooldown
+	}
+	return h
 }
 
-// Find find whether the target matching payload exists
-func (h *HeadlessClient) Find(ctx context.Context, payload *FindRequest) (string, error) {
-	u, err := url.Parse(h.url)
+// Find sends `payload` to headless service, retrying on other
+// endpoints if failed. An error is given if nothing matched.
+func (h *HeadlessClient) Find(ctx context.Context, payload *FindRequest) (*FindRespond, error) {
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return nil, xerrors.Errorf("%w", err)
 	}
 
-	u.Path = path.Join(u.Path, "/v1/find")
-	body, err := json.Marshal(payload)
+	backoff := h.backoff
+	for attempt := 0; ; attempt++ {
+		ep, err := h.pick()
+		if err != nil {
+			return nil, err
+		}
+		resp, retryable, err := h.find(ctx, ep, body)
+		h.report(ep, retryable, err)
+		if err == nil {
+			return resp, nil
+		}
+		if !retryable || attempt >= h.retries || ctx.Err() != nil {
+			return resp, err
+		}
+
+		l.Warnf("headless service %s: %s, retrying", ep.url, err.Error())
+		select {
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("%w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// find makes one attempt on `ep`. `retryable` tells if the error
+// is of the endpoint rather than the request.
+func (h *HeadlessClient) find(ctx context.Context, ep *endpoint, body []byte) (resp *FindRespond, retryable bool, err error) {
+	u, err := url.Parse(ep.url)
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return nil, false, xerrors.Errorf("%w", err)
 	}
+	u.Path = path.Join(u.Path, "/v1/find")
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return nil, false, xerrors.Errorf("%w", err)
 	}
-
 	req.Header.Add("Content-Type", "application/json")
+	h.authorize(req)
 
 	res, err := h.client.Do(req)
-	if res != nil && err != nil {
-		if _, err := io.Copy(io.Discard, res.Body); err != nil {
-			return "", xerrors.Errorf("%w", err)
+	if err != nil {
+		return nil, true, xerrors.Errorf("%w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, true, xerrors.Errorf("headless service responded %s", res.Status)
+	}
+
+	contents, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, xerrors.Errorf("%w", err)
+	}
+	resp = &FindRespond{}
+	if err := json.Unmarshal(contents, resp); err != nil {
+		return nil, false, xerrors.Errorf("%w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return resp, false, xerrors.Errorf("headless service responded %s: %s", res.Status, resp.Message)
+	}
+	if resp.Message != "" {
+		return resp, false, xerrors.Errorf("Error when fetching post from headless browser: %s", resp.Message)
+	}
+	return resp, false, nil
+}
+
+func (h *HeadlessClient) authorize(req *http.Request) {
+	if h.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+h.secret)
+	}
+}
+
+// pick gives the next endpoint not down, round-robin. If all are
+// down, the one to recover soonest is given anyway.
+func (h *HeadlessClient) pick() (*endpoint, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.endpoints) == 0 {
+		return nil, ErrNoEndpoint
+	}
+
+	now := h.now()
+	var soonest *endpoint
+	for i := 0; i < len(h.endpoints); i++ {
+		index := (h.next + i) % len(h.endpoints)
+		ep := h.endpoints[index]
+		if !now.Before(ep.downUntil) {
+			h.next = (index + 1) % len(h.endpoints)
+			return ep, nil
 		}
+		if soonest == nil || ep.downUntil.Before(soonest.downUntil) {
+			soonest = ep
+		}
+	}
+	return soonest, nil
+}
+
+// report takes down `ep` for a while if it failed.
+func (h *HeadlessClient) report(ep *endpoint, retryable bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil && retryable {
+		ep.failures++
+		ep.lastError = err.Error()
+		ep.downUntil = h.now().Add(h.cooldown)
+		return
 	}
+	// Request errors (e.g. nothing matched) are not of the endpoint.
+	ep.successes++
+	ep.downUntil = time.Time{}
+}
 
-	if res != nil {
-		defer res.Body.Close()
+// CheckHealth requests `/healthz` of all endpoints, and marks them
+// accordingly.
+func (h *HeadlessClient) CheckHealth(ctx context.Context) {
+	h.mu.Lock()
+	endpoints := append([]*endpoint{}, h.endpoints...)
+	h.mu.Unlock()
+
+	for _, ep := range endpoints {
+		err := h.checkHealth(ctx, ep)
+		h.mu.Lock()
+		if err != nil {
+			ep.lastError = err.Error()
+			ep.downUntil = h.now().Add(h.cooldown)
+		} else {
+			ep.downUntil = time.Time{}
+		}
+		h.mu.Unlock()
 	}
+}
 
+func (h *HeadlessClient) checkHealth(ctx context.Context, ep *endpoint) error {
+	u, err := url.Parse(ep.url)
+	if err != nil {
+		return xerrors.Errorf("%w", err)
+	}
+	u.Path = path.Join(u.Path, "/healthz")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return xerrors.Errorf("%w", err)
 	}
+	h.authorize(req)
 
-	contents, err := io.ReadAll(res.Body)
+	res, err := h.client.Do(req)
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return xerrors.Errorf("%w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode != http.StatusOK {
+		return xerrors.Errorf("health check responded %s", res.Status)
 	}
+	return nil
+}
 
-	var resBody FindRespond
-	if err := json.Unmarshal(contents, &resBody); err != nil {
-		return "", xerrors.Errorf("%w", err)
+// StartHealthCheck checks endpoints every `interval` until `ctx` is
+// done.
+func (h *HeadlessClient) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
 	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.CheckHealth(ctx)
+			}
+		}
+	}()
+}
 
-	return resBody.Content, nil
+func (h *HeadlessClient) Health() []EndpointHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	result := make([]EndpointHealth, 0, len(h.endpoints))
+	for _, ep := range h.endpoints {
+		health := EndpointHealth{
+			URL:       ep.url,
+			Healthy:   !now.Before(ep.downUntil),
+			Successes: ep.successes,
+			Failures:  ep.failures,
+			LastError: ep.lastError,
+		}
+		if !health.Healthy {
+			health.DownUntil = ep.downUntil
+		}
+		result = append(result, health)
+	}
+	return result
 }
@@ -0,0 +1,97 @@
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFindServer(t *testing.T, status int, respond FindRespond, hits *int32) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		require.Equal(t, "/v1/find", r.URL.Path)
+		require.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(respond)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func Test_HeadlessClient_Find(t *testing.T) {
+	options := ClientOptions{Secret: "s3cret", Retries: 1, Backoff: time.Millisecond}
+
+	t.Run("retry on next endpoint", func(t *testing.T) {
+		var badHits, goodHits int32
+		bad := newFindServer(t, http.StatusBadGateway, FindRespond{}, &badHits)
+		good := newFindServer(t, http.StatusOK, FindRespond{Content: "Sig: abc"}, &goodHits)
+		client := NewHeadlessClient([]string{bad.URL, good.URL}, options)
+
+		resp, err := client.Find(context.Background(), &FindRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "Sig: abc", resp.Content)
+
+		// Failed endpoint is skipped during cooldown.
+		_, err = client.Find(context.Background(), &FindRequest{})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, badHits)
+		require.EqualValues(t, 2, goodHits)
+
+		health := client.Health()
+		require.False(t, health[0].Healthy)
+		require.Contains(t, health[0].LastError, "502")
+		require.True(t, health[1].Healthy)
+	})
+
+	t.Run("no retry on request error", func(t *testing.T) {
+		var hits int32
+		ts := newFindServer(t, http.StatusOK, FindRespond{Message: "timeout"}, &hits)
+		client := NewHeadlessClient([]string{ts.URL, ts.URL}, options)
+
+		_, err := client.Find(context.Background(), &FindRequest{})
+		require.ErrorContains(t, err, "timeout")
+		require.EqualValues(t, 1, hits)
+		require.True(t, client.Health()[0].Healthy)
+	})
+
+	t.Run("all down", func(t *testing.T) {
+		var hits int32
+		ts := newFindServer(t, http.StatusTooManyRequests, FindRespond{}, &hits)
+		client := NewHeadlessClient([]string{ts.URL}, options)
+
+		_, err := client.Find(context.Background(), &FindRequest{})
+		require.ErrorContains(t, err, "429")
+		require.EqualValues(t, 2, hits, "still tried when all endpoints are down")
+	})
+
+	t.Run("no endpoint", func(t *testing.T) {
+		_, err := NewHeadlessClient(nil, options).Find(context.Background(), &FindRequest{})
+		require.ErrorIs(t, err, ErrNoEndpoint)
+	})
+}
+
+func Test_HeadlessClient_CheckHealth(t *testing.T) {
+	healthy := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/healthz", r.URL.Path)
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	client := NewHeadlessClient([]string{ts.URL}, ClientOptions{})
+
+	healthy = false
+	client.CheckHealth(context.Background())
+	require.False(t, client.Health()[0].Healthy)
+
+	healthy = true
+	client.CheckHealth(context.Background())
+	require.True(t, client.Health()[0].Healthy)
+}
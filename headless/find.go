@@ -2,13 +2,14 @@ package headless
 
 import (
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
-	"github.com/ssoroka/slice"
+	"github.com/samber/lo"
 	"golang.org/x/xerrors"
 )
 
@@ -17,6 +18,16 @@ const (
 	matchTypeXPath = "xpath"
 	matchTypeJS    = "js"
 	defaultTimeout = "10s"
+
+	extractText      = "text"
+	extractInnerHTML = "inner_html"
+	extractOuterHTML = "outer_html"
+	extractAttribute = "attribute"
+
+	// defaultMatchLimit is used when `match.all` is set without a
+	// `limit`.
+	defaultMatchLimit = 20
+	maxMatchLimit     = 100
 )
 
 var (
@@ -25,6 +36,22 @@ var (
 		matchTypeXPath: {},
 		matchTypeRegex: {},
 	}
+	validExtracts = map[string]struct{}{
+		"":               {},
+		extractText:      {},
+		extractInnerHTML: {},
+		extractOuterHTML: {},
+		extractAttribute: {},
+	}
+
+	// jsElementsR finds all elements matching `selector` whose text
+	// matches `regex`, without their matched ancestors.
+	jsElementsR = `(selector, regex) => {
+		const m = regex.match(/^\/(.+)\/([a-z]*)$/i)
+		const reg = m ? new RegExp(m[1], m[2]) : new RegExp(regex)
+		const found = Array.from(document.querySelectorAll(selector)).filter((e) => reg.test(e.innerText ?? e.textContent))
+		return found.filter((e) => !found.some((other) => other !== e && e.contains(other)))
+	}`
 )
 
 type MatchRegExp struct {
@@ -50,18 +77,56 @@ type Match struct {
 	MatchRegExp *MatchRegExp `json:"regexp"`
 	MatchXPath  *MatchXPath  `json:"xpath"`
 	MatchJS     *MatchJS     `json:"js"`
+
+	// All gives every matched element instead of the first one, up
+	// to `Limit`. For `js` type, the script should return an array
+	// then.
+	All   bool `json:"all,omitempty"`
+	Limit int  `json:"limit,omitempty"`
+	// Extract is what to take from matched elements: `text`
+	// (default), `inner_html`, `outer_html` or `attribute`.
+	Extract string `json:"extract,omitempty"`
+	// Attribute is the attribute name if `Extract` is `attribute`.
+	Attribute string `json:"attribute,omitempty"`
 }
 
 type FindRequest struct {
 	Location string `json:"location"`
 	Timeout  string `json:"timeout"`
-	Match    Match  `json:"match"`
-	WaitXHR  bool   `json:"wait_xhr"`
+	// Match is optional if `Extractions` is given.
+	Match   Match `json:"match"`
+	WaitXHR bool  `json:"wait_xhr"`
+	// Extractions are run on the same page after `Match`, keyed by
+	// name (e.g. `body`, `author_handle`), in order of name. Each has
+	// its own timeout, and does not wait for elements once `Match` or
+	// an earlier extraction matched.
+	Extractions map[string]Match `json:"extractions,omitempty"`
+	// Capture takes screenshot and / or DOM of the page after all
+	// matches.
+	Capture *CaptureRequest `json:"capture,omitempty"`
+	// Profile is name of the profile to open page with. `default`
+	// profile (if any) is used if empty.
+	Profile string `json:"profile,omitempty"`
+	// NoCache skips `ResultCache` when reading. Result is still
+	// cached for later requests.
+	NoCache bool `json:"no_cache,omitempty"`
 }
 
 type FindRespond struct {
+	// Content is the first result of `match`.
 	Content string `json:"content"`
 	Message string `json:"message,omitempty"`
+	// Results of `match` (keyed by `match`) and each extraction.
+	Results map[string][]string `json:"results,omitempty"`
+	// Errors of extractions which failed, keyed by name.
+	Errors map[string]string `json:"errors,omitempty"`
+	// Capture is given if requested and succeeded. Otherwise the error
+	// is in `Errors["capture"]`.
+	Capture *Capture `json:"capture,omitempty"`
+	// Cached is true if given from `ResultCache`.
+	Cached bool `json:"cached,omitempty"`
+	// FetchedAt is when the page was rendered.
+	FetchedAt time.Time `json:"fetched_at,omitempty"`
 }
 
 func errorResp(c *gin.Context, error_code int, err error) {
@@ -81,13 +146,44 @@ func validate(c *gin.Context) {
 		errorResp(c, http.StatusBadRequest, err)
 		return
 	}
+	profile, err := getProfile(req.Profile)
+	if err != nil {
+		errorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	location := ReplaceLocation(req.Location)
+	if err := profile.CheckLocation(location); err != nil {
+		errorResp(c, http.StatusForbidden, err)
+		return
+	}
+	if err := CheckLocation(c.Request.Context(), location); err != nil {
+		code := http.StatusBadRequest
+		if xerrors.Is(err, ErrHostBlocked) {
+			code = http.StatusForbidden
+		}
+		errorResp(c, code, err)
+		return
+	}
 
-	page, err := Browser.Page(proto.TargetCreateTarget{URL: ReplaceLocation(req.Location)})
+	cacheKey := CacheKey(location, &req)
+	if ResultCache != nil && !req.NoCache {
+		if cached, ok := ResultCache.Get(cacheKey); ok {
+			cached.Cached = true
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+	}
+
+	page, cleanup, err := Pool.Page(c.Request.Context())
+	if xerrors.Is(err, ErrPoolBusy) {
+		errorResp(c, http.StatusTooManyRequests, err)
+		return
+	}
 	if err != nil {
-		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
+		errorResp(c, http.StatusServiceUnavailable, xerrors.Errorf("%w", err))
 		return
 	}
-	defer page.Close()
+	defer cleanup()
 
 	timeout := req.Timeout
 	if timeout == "" {
@@ -99,51 +195,151 @@ func validate(c *gin.Context) {
 		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
 		return
 	}
-	router := page.HijackRequests()
-
-	resources := []proto.NetworkResourceType{
-		proto.NetworkResourceTypeFont,
-		proto.NetworkResourceTypeImage,
-		proto.NetworkResourceTypeMedia,
-		proto.NetworkResourceTypeStylesheet,
-		proto.NetworkResourceTypeWebSocket, // we don't need websockets to fetch html
+	if err := profile.Apply(page); err != nil {
+		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
+		return
 	}
+	router := page.HijackRequests()
 
+	guard := newHostGuard(c.Request.Context())
 	router.MustAdd("*", func(ctx *rod.Hijack) {
-		if slice.Contains(resources, ctx.Request.Type()) {
+		// Redirects come here as new requests too.
+		if err := guard.Check(ctx.Request.URL()); err != nil {
+			l.Warnf("blocked request to %s: %s", ctx.Request.URL().String(), err.Error())
+			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+			return
+		}
+		if profile.Blocks(ctx.Request.URL().String(), ctx.Request.Type()) {
 			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
 			return
 		}
 
-		ctx.ContinueRequest(&proto.FetchContinueRequest{})
+		ctx.ContinueRequest(&proto.FetchContinueRequest{
+			Headers: profile.RequestHeaders(ctx.Request.URL(), ctx.Request.Headers()),
+		})
 	})
 
 	go router.Run()
 	defer router.Stop()
 
+	// Extractions and capture get their own timeout from `base`, so
+	// that one of them timed out does not fail the others.
+	base := page
 	page = page.Timeout(timeoutDuration)
-	if err := page.WaitLoad(); err != nil {
+	// Armed before navigation, or idle event may have been missed.
+	var waitIdle func()
+	if req.WaitXHR || profile.Wait == WaitNetworkIdle {
+		waitIdle = page.WaitNavigation(proto.PageLifecycleEventNameNetworkAlmostIdle)
+	}
+	if err := page.Navigate(location); err != nil {
 		errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
 		return
 	}
+	if profile.Wait != WaitNone {
+		if err := page.WaitLoad(); err != nil {
+			errorResp(c, http.StatusInternalServerError, xerrors.Errorf("%w", err))
+			return
+		}
+	}
 
 	// Wait for XHR
-	if req.WaitXHR {
-		page.WaitNavigation(proto.PageLifecycleEventNameNetworkAlmostIdle)()
+	if waitIdle != nil {
+		waitIdle()
 	}
 
-	content, err := find(req.Match, page)
-	if err != nil {
-		c.JSON(http.StatusOK, FindRespond{Content: "", Message: err.Error()})
+	resp := FindRespond{Results: map[string][]string{}}
+	if req.Match.Type != "" {
+		values, err := find(req.Match, page)
+		if err != nil {
+			c.JSON(http.StatusOK, FindRespond{Content: "", Message: err.Error()})
 
-		return
+			return
+		}
+		resp.Content = values[0]
+		resp.Results["match"] = values
+	}
+
+	// Once anything matched, page is taken as rendered and
+	// extractions do not wait for their elements.
+	matched := req.Match.Type != ""
+	names := lo.Keys(req.Extractions)
+	sort.Strings(names)
+	for _, name := range names {
+		lookup := base.Timeout(timeoutDuration)
+		if matched {
+			lookup = lookup.Sleeper(rod.NotFoundSleeper)
+		}
+		values, err := find(req.Extractions[name], lookup)
+		lookup.CancelTimeout()
+		if err != nil {
+			if resp.Errors == nil {
+				resp.Errors = map[string]string{}
+			}
+			resp.Errors[name] = err.Error()
+			continue
+		}
+		resp.Results[name] = values
+		matched = true
+	}
+
+	if req.Capture != nil {
+		captureCtx := base.Timeout(timeoutDuration)
+		captured, err := capture(req.Capture, captureCtx)
+		captureCtx.CancelTimeout()
+		if err != nil {
+			if resp.Errors == nil {
+				resp.Errors = map[string]string{}
+			}
+			resp.Errors["capture"] = err.Error()
+		} else {
+			resp.Capture = captured
+		}
+	}
+
+	resp.FetchedAt = time.Now()
+	if ResultCache != nil && cacheable(&resp) {
+		ResultCache.Set(cacheKey, cacheEntry(&resp))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// find gives extracted values of matched elements. At least one value
+// is given if no error.
+func find(match Match, page *rod.Page) (values []string, err error) {
+	element, err := findFirst(match, page)
+	if err != nil {
+		return nil, err
+	}
+	elements := rod.Elements{element}
+	if match.All {
+		if elements, err = findAll(match, page); err != nil {
+			return nil, err
+		}
+		limit := match.Limit
+		if limit <= 0 {
+			limit = defaultMatchLimit
+		}
+		if len(elements) > limit {
+			elements = elements[:limit]
+		}
 	}
 
-	c.JSON(http.StatusOK, FindRespond{Content: content})
+	values = make([]string, 0, len(elements))
+	for _, element := range elements {
+		value, err := extract(match, element)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return nil, xerrors.Errorf("no element matched")
+	}
+	return values, nil
 }
 
-func find(match Match, page *rod.Page) (content string, err error) {
-	var element *rod.Element
+// findFirst waits until an element matches.
+func findFirst(match Match, page *rod.Page) (element *rod.Element, err error) {
 	switch match.Type {
 	case matchTypeRegex:
 		if match.MatchRegExp.Selector == "" {
@@ -152,28 +348,75 @@ func find(match Match, page *rod.Page) (content string, err error) {
 
 		element, err = page.ElementR(match.MatchRegExp.Selector, match.MatchRegExp.Value)
 		if err != nil {
-			return "", xerrors.Errorf("%w", err)
+			return nil, xerrors.Errorf("%w", err)
 		}
 	case matchTypeXPath:
 		element, err = page.ElementX(match.MatchXPath.Selector)
 		if err != nil {
-			return "", xerrors.Errorf("%w", err)
+			return nil, xerrors.Errorf("%w", err)
 		}
 	case matchTypeJS:
 		element, err = page.ElementByJS(rod.Eval(match.MatchJS.Value))
 		if err != nil {
-			return "", xerrors.Errorf("%w", err)
+			return nil, xerrors.Errorf("%w", err)
 		}
 	default:
-		return "", xerrors.Errorf("%s", "invalid payload")
+		return nil, xerrors.Errorf("%s", "invalid payload")
 	}
 
-	text, err := element.Text()
+	return element, nil
+}
+
+// findAll gives all elements matched at the moment.
+func findAll(match Match, page *rod.Page) (elements rod.Elements, err error) {
+	switch match.Type {
+	case matchTypeRegex:
+		elements, err = page.ElementsByJS(rod.Eval(jsElementsR, match.MatchRegExp.Selector, match.MatchRegExp.Value))
+	case matchTypeXPath:
+		elements, err = page.ElementsX(match.MatchXPath.Selector)
+	case matchTypeJS:
+		elements, err = page.ElementsByJS(rod.Eval(match.MatchJS.Value))
+	default:
+		return nil, xerrors.Errorf("%s", "invalid payload")
+	}
 	if err != nil {
-		return "", xerrors.Errorf("%w", err)
+		return nil, xerrors.Errorf("%w", err)
 	}
+	return elements, nil
+}
 
-	return text, nil
+func extract(match Match, element *rod.Element) (string, error) {
+	switch match.Extract {
+	case "", extractText:
+		text, err := element.Text()
+		if err != nil {
+			return "", xerrors.Errorf("%w", err)
+		}
+		return text, nil
+	case extractInnerHTML:
+		res, err := element.Eval(`() => this.innerHTML`)
+		if err != nil {
+			return "", xerrors.Errorf("%w", err)
+		}
+		return res.Value.Str(), nil
+	case extractOuterHTML:
+		html, err := element.HTML()
+		if err != nil {
+			return "", xerrors.Errorf("%w", err)
+		}
+		return html, nil
+	case extractAttribute:
+		value, err := element.Attribute(match.Attribute)
+		if err != nil {
+			return "", xerrors.Errorf("%w", err)
+		}
+		if value == nil {
+			return "", nil
+		}
+		return *value, nil
+	default:
+		return "", xerrors.Errorf("%s", "invalid payload")
+	}
 }
 
 func checkValidateRequest(req *FindRequest) error {
@@ -187,40 +430,78 @@ func checkValidateRequest(req *FindRequest) error {
 		}
 	}
 
-	if _, ok := validMatchTypes[req.Match.Type]; !ok {
-		return xerrors.Errorf("'match.type' should be 'regexp', 'xpath', or 'js'")
+	if req.Match.Type != "" || len(req.Extractions) == 0 {
+		if err := checkMatch("match", &req.Match); err != nil {
+			return err
+		}
+	}
+	for name, match := range req.Extractions {
+		if name == "match" || name == "capture" {
+			return xerrors.Errorf("'extractions.%s' is reserved", name)
+		}
+		if err := checkMatch("extractions."+name, &match); err != nil {
+			return err
+		}
+		req.Extractions[name] = match
 	}
 
-	if req.Match.Type == matchTypeRegex {
-		if req.Match.MatchRegExp == nil {
-			return xerrors.Errorf("'match.regexp' payload is missing")
+	return nil
+}
+
+func checkMatch(field string, match *Match) error {
+	if _, ok := validMatchTypes[match.Type]; !ok {
+		return xerrors.Errorf("'%s.type' should be 'regexp', 'xpath', or 'js'", field)
+	}
+
+	if match.Type == matchTypeRegex {
+		if match.MatchRegExp == nil {
+			return xerrors.Errorf("'%s.regexp' payload is missing", field)
 		}
 
-		if req.Match.MatchRegExp.Value == "" {
-			return xerrors.Errorf("'match.regexp.value' must be specified")
+		if match.MatchRegExp.Value == "" {
+			return xerrors.Errorf("'%s.regexp.value' must be specified", field)
+		}
+
+		if match.MatchRegExp.Selector == "" {
+			match.MatchRegExp.Selector = "*"
 		}
 	}
 
-	if req.Match.Type == matchTypeXPath {
-		if req.Match.MatchXPath == nil {
-			return xerrors.Errorf("'match.xpath' payload is missing")
+	if match.Type == matchTypeXPath {
+		if match.MatchXPath == nil {
+			return xerrors.Errorf("'%s.xpath' payload is missing", field)
 		}
 
-		if req.Match.MatchXPath.Selector == "" {
-			return xerrors.Errorf("'match.xpath.selector' must be specified")
+		if match.MatchXPath.Selector == "" {
+			return xerrors.Errorf("'%s.xpath.selector' must be specified", field)
 		}
 	}
 
-	if req.Match.Type == matchTypeJS {
-		if req.Match.MatchJS == nil {
-			return xerrors.Errorf("'match.js' payload is missing")
+	if match.Type == matchTypeJS {
+		if DisableJS {
+			return xerrors.Errorf("'%s.type' 'js' is disabled", field)
+		}
+		if match.MatchJS == nil {
+			return xerrors.Errorf("'%s.js' payload is missing", field)
 		}
 
-		if req.Match.MatchJS.Value == "" {
-			return xerrors.Errorf("'match.js.value' must be specified")
+		if match.MatchJS.Value == "" {
+			return xerrors.Errorf("'%s.js.value' must be specified", field)
 		}
 	}
 
+	if _, ok := validExtracts[match.Extract]; !ok {
+		return xerrors.Errorf("'%s.extract' should be 'text', 'inner_html', 'outer_html', or 'attribute'", field)
+	}
+
+	if match.Extract == extractAttribute && match.Attribute == "" {
+		return xerrors.Errorf("'%s.attribute' must be specified", field)
+	}
+
+	if match.Limit < 0 || match.Limit > maxMatchLimit {
+		return xerrors.Errorf("'%s.limit' should be between 0 and %d", field, maxMatchLimit)
+	}
+
 	return nil
 }
 
@@ -1,13 +1,17 @@
 package headless_test
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/nextdotid/proof_server/headless"
 )
@@ -189,3 +193,229 @@ func Test_ReplaceLocation(t *testing.T) {
 		assert.Equal(t, expectedURL, headless.ReplaceLocation(originalURL))
 	})
 }
+
+func Test_Find_extractions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`
+                <html>
+                  <body>
+                    <article>
+                      <a class="author" href="/yeiwb" data-id="42">@yeiwb</a>
+                      <p>Sig: first</p>
+                      <p>Sig: second</p>
+                      <p>Sig: third</p>
+                    </article>
+                  </body>
+                </html>`))
+	}))
+	defer ts.Close()
+
+	t.Run("success", func(t *testing.T) {
+		headless.InitBrowser()
+		defer headless.Browser.Close()
+
+		req := newValidRequest(ts.URL, "regexp")
+		req.Match.All = true
+		req.Match.Limit = 2
+		req.Extractions = map[string]headless.Match{
+			"author_handle": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//a[@class='author']"},
+			},
+			"author_id": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//a[@class='author']"},
+				Extract:    "attribute",
+				Attribute:  "data-id",
+			},
+			"article": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//article"},
+				Extract:    "inner_html",
+			},
+		}
+		res := headless.FindRespond{}
+		APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+
+		assert.Equal(t, "", res.Message)
+		assert.Equal(t, "Sig: first", res.Content)
+		assert.Equal(t, []string{"Sig: first", "Sig: second"}, res.Results["match"])
+		assert.Equal(t, []string{"@yeiwb"}, res.Results["author_handle"])
+		assert.Equal(t, []string{"42"}, res.Results["author_id"])
+		assert.Contains(t, res.Results["article"][0], `<p>Sig: third</p>`)
+		assert.Empty(t, res.Errors)
+	})
+
+	t.Run("missing extraction", func(t *testing.T) {
+		headless.InitBrowser()
+		defer headless.Browser.Close()
+
+		req := newValidRequest(ts.URL, "regexp")
+		req.Extractions = map[string]headless.Match{
+			"a_missing": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//nav"},
+			},
+			"author_handle": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//a[@class='author']"},
+			},
+		}
+		req.Capture = &headless.CaptureRequest{HTML: true}
+		res := headless.FindRespond{}
+		started := time.Now()
+		APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+
+		assert.Less(t, time.Since(started), 2*time.Second, "missing one does not wait for timeout")
+		assert.Contains(t, res.Errors, "a_missing")
+		assert.Equal(t, []string{"@yeiwb"}, res.Results["author_handle"])
+		assert.NotNil(t, res.Capture)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		req := newValidRequest(ts.URL, "regexp")
+		req.Extractions = map[string]headless.Match{
+			"author_id": {
+				Type:       "xpath",
+				MatchXPath: &headless.MatchXPath{Selector: "//a"},
+				Extract:    "attribute",
+			},
+		}
+		res := headless.FindRespond{}
+		APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+
+		assert.Contains(t, res.Message, "extractions.author_id.attribute")
+
+		req = newValidRequest(ts.URL, "regexp")
+		req.Match.Limit = 1000
+		res = headless.FindRespond{}
+		APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+
+		assert.Contains(t, res.Message, "match.limit")
+	})
+}
+
+func Test_Find_capture(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><p>Sig: first</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	headless.InitBrowser()
+	defer headless.Browser.Close()
+
+	req := newValidRequest(ts.URL, "regexp")
+	req.Capture = &headless.CaptureRequest{Screenshot: true, HTML: true}
+	res := headless.FindRespond{}
+	APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+
+	assert.Equal(t, "", res.Message)
+	assert.Empty(t, res.Errors)
+	require.NotNil(t, res.Capture)
+	assert.False(t, res.Capture.Stored, "inline without storage")
+	assert.Len(t, res.Capture.ScreenshotHash, 64)
+	assert.NotEmpty(t, res.Capture.Screenshot)
+	assert.Len(t, res.Capture.HTMLHash, 64)
+	html, err := base64.StdEncoding.DecodeString(res.Capture.HTML)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<p>Sig: first</p>")
+}
+
+func Test_Find_profile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("session")
+		session := ""
+		if cookie != nil {
+			session = cookie.Value
+		}
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><body><p id="seen">%s|%s|%s</p></body></html>`, session, r.Header.Get("X-Test"), r.UserAgent())
+	}))
+	defer ts.Close()
+
+	original := headless.Profiles
+	defer func() { headless.Profiles = original }()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(`{
+		"logged_in": {
+			"hosts": ["127.0.0.1"],
+			"cookies": [{"name": "session", "value": "abc", "url": "%s"}],
+			"headers": {"X-Test": "yes"},
+			"user_agent": "proof-test",
+			"wait": "none"
+		}
+	}`, ts.URL)), 0o644))
+	require.NoError(t, headless.LoadProfiles(path))
+
+	headless.InitBrowser()
+	defer headless.Browser.Close()
+
+	req := headless.FindRequest{
+		Location: ts.URL,
+		Timeout:  "2s",
+		Profile:  "logged_in",
+		Match: headless.Match{
+			Type:       "xpath",
+			MatchXPath: &headless.MatchXPath{Selector: "//p[@id='seen']"},
+		},
+	}
+	res := headless.FindRespond{}
+	APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+	assert.Equal(t, "", res.Message)
+	assert.Equal(t, "abc|yes|proof-test", res.Content)
+
+	req.Profile = "unknown"
+	res = headless.FindRespond{}
+	w := APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, res.Message, "'profile' unknown is unknown")
+
+	// Headers and cookies never go out of hosts of profile.
+	req.Profile = "logged_in"
+	req.Location = "https://example.com/"
+	res = headless.FindRespond{}
+	w = APITestCall(headless.Engine, "POST", "/v1/find", req, &res)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, res.Message, "is not of profile")
+}
+
+func Test_Find_cache(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			hits++
+		}
+		w.Header().Add("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><p>Sig: first</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	original := headless.ResultCache
+	headless.ResultCache = headless.NewMemoryCache(time.Minute, 0)
+	defer func() { headless.ResultCache = original }()
+
+	headless.InitBrowser()
+	defer headless.Browser.Close()
+
+	req := newValidRequest(ts.URL, "regexp")
+	first := headless.FindRespond{}
+	APITestCall(headless.Engine, "POST", "/v1/find", req, &first)
+	assert.Equal(t, "Sig: first", first.Content)
+	assert.False(t, first.Cached)
+	assert.False(t, first.FetchedAt.IsZero())
+
+	second := headless.FindRespond{}
+	APITestCall(headless.Engine, "POST", "/v1/find", req, &second)
+	assert.Equal(t, "Sig: first", second.Content)
+	assert.True(t, second.Cached)
+	assert.True(t, first.FetchedAt.Equal(second.FetchedAt))
+	assert.Equal(t, 1, hits)
+
+	req.NoCache = true
+	third := headless.FindRespond{}
+	APITestCall(headless.Engine, "POST", "/v1/find", req, &third)
+	assert.False(t, third.Cached)
+	assert.Equal(t, 2, hits)
+}
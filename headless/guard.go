@@ -0,0 +1,301 @@
+package headless
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// HeaderTimestamp and HeaderSignature carry HMAC auth, as an
+	// alternative to bearer token which keeps secret off the wire.
+	// Signature is hex(HMAC-SHA256(secret, timestamp + "\n" + body)).
+	HeaderTimestamp = "X-Headless-Timestamp"
+	HeaderSignature = "X-Headless-Signature"
+
+	// signatureMaxAge limits replay of HMAC-signed requests.
+	signatureMaxAge = 5 * time.Minute
+	resolveTimeout  = 5 * time.Second
+)
+
+var (
+	ErrUnauthorized = xerrors.New("unauthorized")
+	ErrHostBlocked  = xerrors.New("host is not allowed")
+
+	// AuthSecret is required from clients if set, as bearer token or
+	// HMAC signature.
+	AuthSecret string
+	// AllowedHosts are hosts (with their subdomains) which can be
+	// opened. Any host if empty.
+	AllowedHosts []string
+	// DeniedHosts are hosts (with their subdomains) which cannot be
+	// opened. Checked before `AllowedHosts`.
+	DeniedHosts []string
+	// AllowPrivateIPs allows hosts resolved to loopback, private or
+	// link-local addresses.
+	AllowPrivateIPs = false
+	// DisableJS rejects matches of `js` type.
+	DisableJS = false
+	// RateLimit is how many requests per second one client (by IP)
+	// can make, bursting up to `RateBurst`. 0 disables the limit.
+	RateLimit float64
+	RateBurst = 10
+
+	limiter     *rateLimiter
+	limiterOnce sync.Once
+
+	// Replaceable for testing.
+	lookupIP = net.DefaultResolver.LookupIPAddr
+)
+
+// middlewareAuth checks `AuthSecret` of request.
+func middlewareAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if AuthSecret == "" {
+			c.Next()
+			return
+		}
+		if err := authorize(c.Request, time.Now()); err != nil {
+			errorResp(c, http.StatusUnauthorized, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func authorize(req *http.Request, now time.Time) error {
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(AuthSecret)) == 1 {
+			return nil
+		}
+		return ErrUnauthorized
+	}
+
+	timestamp, signature := req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature)
+	if timestamp == "" || signature == "" {
+		return ErrUnauthorized
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > signatureMaxAge || age < -signatureMaxAge {
+		return xerrors.Errorf("%w: signature expired", ErrUnauthorized)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return xerrors.Errorf("%w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, Sign(AuthSecret, timestamp, body)) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Sign gives HMAC signature of request `body` sent at `timestamp`
+// (unix seconds).
+func Sign(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// middlewareRateLimit limits requests of each client by `RateLimit`.
+func middlewareRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if RateLimit <= 0 {
+			c.Next()
+			return
+		}
+		limiterOnce.Do(func() { limiter = newRateLimiter(RateLimit, RateBurst) })
+		if !limiter.Allow(c.ClientIP(), time.Now()) {
+			errorResp(c, http.StatusTooManyRequests, xerrors.New("rate limit exceeded"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimiter is a token bucket per client.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: map[string]*bucket{}}
+}
+
+func (r *rateLimiter) Allow(client string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[client]
+	if !ok {
+		// Forget clients whose bucket is full again.
+		if len(r.buckets) >= 1024 {
+			for key, other := range r.buckets {
+				if now.Sub(other.last).Seconds()*r.rate >= r.burst {
+					delete(r.buckets, key)
+				}
+			}
+		}
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// CheckLocation tells if `location` can be opened, by scheme, host
+// lists and resolved addresses of the host.
+func CheckLocation(ctx context.Context, location string) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return xerrors.Errorf("%w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return xerrors.Errorf("%w: scheme %q", ErrHostBlocked, u.Scheme)
+	}
+	return checkHost(ctx, u.Hostname())
+}
+
+// checkHost tells if `host` can be opened.
+func checkHost(ctx context.Context, host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return xerrors.Errorf("%w: empty host", ErrHostBlocked)
+	}
+	if matchHost(host, DeniedHosts) {
+		return xerrors.Errorf("%w: %s", ErrHostBlocked, host)
+	}
+	if len(AllowedHosts) > 0 && !matchHost(host, AllowedHosts) {
+		return xerrors.Errorf("%w: %s", ErrHostBlocked, host)
+	}
+	if AllowPrivateIPs {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateIP(ip) {
+			return xerrors.Errorf("%w: %s is a private address", ErrHostBlocked, host)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := lookupIP(ctx, host)
+	if err != nil {
+		return xerrors.Errorf("resolving %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if IsPrivateIP(addr.IP) {
+			return xerrors.Errorf("%w: %s resolves to private address %s", ErrHostBlocked, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// matchHost tells if `host` is one of `patterns` or their subdomain.
+func matchHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.ToLower(pattern), ".")
+		if pattern == "" {
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivateIP tells if `ip` is loopback, private, link-local or
+// otherwise not reachable publicly.
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified() ||
+		// Carrier-grade NAT, used by some cloud metadata services.
+		(ip.To4() != nil && ip.To4()[0] == 100 && ip.To4()[1]&0xc0 == 64)
+}
+
+// hostGuard checks hosts of all requests made by one page, so
+// redirects and subresources cannot reach hosts not allowed. Result
+// of each host is kept for the page.
+type hostGuard struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	checked map[string]error
+}
+
+func newHostGuard(ctx context.Context) *hostGuard {
+	return &hostGuard{ctx: ctx, checked: map[string]error{}}
+}
+
+func (g *hostGuard) Check(u *url.URL) error {
+	switch u.Scheme {
+	case "data", "blob":
+		return nil
+	case "http", "https":
+	default:
+		return xerrors.Errorf("%w: scheme %q", ErrHostBlocked, u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	g.mu.Lock()
+	err, ok := g.checked[host]
+	g.mu.Unlock()
+	if ok {
+		return err
+	}
+	err = checkHost(g.ctx, host)
+	g.mu.Lock()
+	g.checked[host] = err
+	g.mu.Unlock()
+	return err
+}
@@ -0,0 +1,136 @@
+package headless
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_authorize(t *testing.T) {
+	original := AuthSecret
+	AuthSecret = "s3cret"
+	t.Cleanup(func() { AuthSecret = original })
+
+	now := time.Unix(1700000000, 0)
+	body := `{"location":"https://example.com"}`
+	newRequest := func(header map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/v1/find", strings.NewReader(body))
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		return req
+	}
+	sign := func(timestamp int64, body string) string {
+		return hex.EncodeToString(Sign("s3cret", strconv.FormatInt(timestamp, 10), []byte(body)))
+	}
+
+	require.NoError(t, authorize(newRequest(map[string]string{"Authorization": "Bearer s3cret"}), now))
+	require.ErrorIs(t, authorize(newRequest(map[string]string{"Authorization": "Bearer wrong"}), now), ErrUnauthorized)
+	require.ErrorIs(t, authorize(newRequest(nil), now), ErrUnauthorized)
+
+	req := newRequest(map[string]string{
+		HeaderTimestamp: strconv.FormatInt(now.Unix(), 10),
+		HeaderSignature: sign(now.Unix(), body),
+	})
+	require.NoError(t, authorize(req, now))
+	require.NotNil(t, req.Body, "body is kept for handler")
+
+	req = newRequest(map[string]string{
+		HeaderTimestamp: strconv.FormatInt(now.Unix(), 10),
+		HeaderSignature: sign(now.Unix(), `{"location":"http://169.254.169.254"}`),
+	})
+	require.ErrorIs(t, authorize(req, now), ErrUnauthorized, "body tampered")
+
+	old := now.Add(-10 * time.Minute).Unix()
+	req = newRequest(map[string]string{
+		HeaderTimestamp: strconv.FormatInt(old, 10),
+		HeaderSignature: sign(old, body),
+	})
+	require.ErrorContains(t, authorize(req, now), "expired")
+}
+
+func Test_rateLimiter(t *testing.T) {
+	limiter := newRateLimiter(1, 2)
+	now := time.Now()
+
+	require.True(t, limiter.Allow("a", now))
+	require.True(t, limiter.Allow("a", now))
+	require.False(t, limiter.Allow("a", now))
+	require.True(t, limiter.Allow("b", now), "clients are limited separately")
+	require.True(t, limiter.Allow("a", now.Add(time.Second)))
+}
+
+func Test_middlewareRateLimit_forwardedFor(t *testing.T) {
+	originalRate, originalBurst := RateLimit, RateBurst
+	RateLimit, RateBurst = 1, 1
+	limiter, limiterOnce = nil, sync.Once{}
+	t.Cleanup(func() {
+		RateLimit, RateBurst = originalRate, originalBurst
+		limiter, limiterOnce = nil, sync.Once{}
+	})
+
+	engine, err := newEngine()
+	require.NoError(t, err)
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/find", strings.NewReader("{}"))
+		req.RemoteAddr = "203.0.113.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.NotEqual(t, http.StatusTooManyRequests, request("198.51.100.1"))
+	require.Equal(t, http.StatusTooManyRequests, request("198.51.100.2"), "spoofed X-Forwarded-For gets no new bucket")
+}
+
+func Test_CheckLocation(t *testing.T) {
+	originalLookup, originalAllowed, originalDenied, originalPrivate := lookupIP, AllowedHosts, DeniedHosts, AllowPrivateIPs
+	t.Cleanup(func() {
+		lookupIP, AllowedHosts, DeniedHosts, AllowPrivateIPs = originalLookup, originalAllowed, originalDenied, originalPrivate
+	})
+	AllowPrivateIPs = false
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+	ctx := context.Background()
+
+	require.NoError(t, CheckLocation(ctx, "https://x.com/yeiwb/status/1"))
+	for _, location := range []string{
+		"file:///etc/passwd",
+		"http://127.0.0.1:9801/healthz",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/",
+		"http://100.100.100.200/",
+		"http://internal.example.com/",
+	} {
+		require.ErrorIs(t, CheckLocation(ctx, location), ErrHostBlocked, location)
+	}
+
+	AllowedHosts = []string{"x.com", "twitter.com"}
+	DeniedHosts = []string{"ads.twitter.com"}
+	require.NoError(t, CheckLocation(ctx, "https://mobile.twitter.com/yeiwb"))
+	require.ErrorIs(t, CheckLocation(ctx, "https://ads.twitter.com/"), ErrHostBlocked)
+	require.ErrorIs(t, CheckLocation(ctx, "https://notx.com/"), ErrHostBlocked)
+}
+
+func Test_checkMatch_disableJS(t *testing.T) {
+	original := DisableJS
+	DisableJS = true
+	t.Cleanup(func() { DisableJS = original })
+
+	err := checkMatch("match", &Match{Type: matchTypeJS, MatchJS: &MatchJS{Value: "() => document.body"}})
+	require.ErrorContains(t, err, "'match.type' 'js' is disabled")
+}
@@ -1,15 +1,16 @@
 package headless
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/launcher"
 	"github.com/nextdotid/proof_server/common"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
 )
 
 var (
@@ -18,10 +19,20 @@ var (
 	l              = logrus.WithFields(logrus.Fields{"module": "headless"})
 	URLReplacement = map[string]string{}
 	Browser        *rod.Browser
+	// AllowedOrigins are origins allowed by CORS. The service is called
+	// by servers, so no browser origin is allowed if empty.
+	AllowedOrigins []string
+	// TrustedProxies are addresses (or CIDRs) of reverse proxies whose
+	// `X-Forwarded-For` is trusted for client IP. Client IP is the
+	// address connected if empty.
+	TrustedProxies []string
 )
 
 func middlewareCors() gin.HandlerFunc {
-	return cors.Default()
+	config := cors.DefaultConfig()
+	config.AllowOrigins = AllowedOrigins
+	config.AllowHeaders = append(config.AllowHeaders, "Authorization", HeaderTimestamp, HeaderSignature)
+	return cors.New(config)
 }
 
 func Init(launcherPath string, urlReplacementRule string) {
@@ -30,13 +41,33 @@ func Init(launcherPath string, urlReplacementRule string) {
 		return
 	}
 
-	InitBrowser()
+	// Browser will be relaunched by probe or next request if failed.
+	if err := InitBrowser(); err != nil {
+		l.Errorf("initializing browser: %s", err.Error())
+	}
+	Pool.StartProbe(context.Background(), ProbeInterval)
 	InitUrlReplacementRule(urlReplacementRule)
-	Engine = gin.Default()
-	Engine.Use(middlewareCors())
+	engine, err := newEngine()
+	if err != nil {
+		l.Fatalf("initializing engine: %s", err.Error())
+	}
+	Engine = engine
+}
+
+func newEngine() (*gin.Engine, error) {
+	engine := gin.Default()
+	// gin trusts all proxies by default, which lets any client choose
+	// its IP (for rate limit) by `X-Forwarded-For`.
+	if err := engine.SetTrustedProxies(TrustedProxies); err != nil {
+		return nil, xerrors.Errorf("trusted proxies: %w", err)
+	}
+	if len(AllowedOrigins) > 0 {
+		engine.Use(middlewareCors())
+	}
 
-	Engine.GET("/healthz", healthz)
-	Engine.POST("/v1/find", validate)
+	engine.GET("/healthz", healthz)
+	engine.POST("/v1/find", middlewareRateLimit(), middlewareAuth(), validate)
+	return engine, nil
 }
 
 func healthz(c *gin.Context) {
@@ -46,6 +77,7 @@ func healthz(c *gin.Context) {
 		"revision":    common.Revision,
 		"built_at":    common.BuildTime,
 		"runtime":     common.CurrentRuntime,
+		"pool":        Pool.Health(),
 	})
 }
 
@@ -65,25 +97,10 @@ func InitUrlReplacementRule(rule string) {
 	}
 }
 
-func InitBrowser() {
-	var launcher *launcher.Launcher
-	switch common.CurrentRuntime {
-	case common.Runtimes.Lambda:
-		launcher = newLambdaLauncher(LauncherPath)
-	case common.Runtimes.Standalone:
-		launcher = newLauncher(LauncherPath)
-	}
-	// defer launcher.Kill()
-	// defer launcher.Cleanup()
-
-	u, err := launcher.Launch()
-	if err != nil {
-		panic(err.Error())
-	}
-
-	Browser = rod.New().ControlURL(u)
-	// defer browser.Close()
-	if err := Browser.Connect(); err != nil {
-		panic(err.Error())
+// InitBrowser (re)launches the browser of `Pool`.
+func InitBrowser() error {
+	if Pool == nil {
+		Pool = NewBrowserPool(MaxPages, QueueSize, QueueTimeout)
 	}
+	return Pool.Relaunch()
 }
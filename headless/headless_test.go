@@ -16,6 +16,8 @@ import (
 
 func TestMain(m *testing.M) {
 	config.Init("../config/config.test.json")
+	// Test pages are served on loopback.
+	headless.AllowPrivateIPs = true
 	headless.Init("", "")
 	os.Exit(m.Run())
 }
@@ -0,0 +1,298 @@
+package headless
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/nextdotid/proof_server/common"
+	"golang.org/x/xerrors"
+)
+
+const (
+	probeTimeout = 5 * time.Second
+)
+
+var (
+	// ErrPoolBusy means both pages and queue of the pool are full.
+	ErrPoolBusy = xerrors.New("too many requests in queue")
+
+	// MaxPages is how many pages can be open at the same time.
+	MaxPages = 4
+	// QueueSize is how many requests can wait for a page. Requests
+	// beyond it are rejected at once.
+	QueueSize = 16
+	// QueueTimeout is how long a request can wait for a page.
+	QueueTimeout = 30 * time.Second
+	// ProbeInterval is how often the browser is checked. 0 disables
+	// the periodic probe.
+	ProbeInterval = 30 * time.Second
+
+	Pool *BrowserPool
+)
+
+// BrowserPool limits concurrent pages on a browser, and relaunches the
+// browser when it is found dead. Each page is opened in its own
+// incognito context.
+type BrowserPool struct {
+	mu sync.Mutex
+	// launchMu serializes relaunches, which are slow. `mu` is not
+	// held while launching.
+	launchMu sync.Mutex
+	browser  *rod.Browser
+	launcher *launcher.Launcher
+	// launch starts a browser. Replaceable for testing.
+	launch func() (*rod.Browser, *launcher.Launcher, error)
+
+	slots        chan struct{}
+	queueSize    int
+	queueTimeout time.Duration
+	waiting      int
+
+	launches    int
+	lastProbeAt time.Time
+	lastError   string
+	healthy     bool
+}
+
+// PoolHealth is the state of the pool.
+type PoolHealth struct {
+	MaxPages    int       `json:"max_pages"`
+	InUse       int       `json:"in_use"`
+	Waiting     int       `json:"waiting"`
+	QueueSize   int       `json:"queue_size"`
+	Healthy     bool      `json:"healthy"`
+	Launches    int       `json:"launches"`
+	LastProbeAt time.Time `json:"last_probe_at"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+func NewBrowserPool(maxPages, queueSize int, queueTimeout time.Duration) *BrowserPool {
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	return &BrowserPool{
+		launch:       launchBrowser,
+		slots:        make(chan struct{}, maxPages),
+		queueSize:    queueSize,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire takes a page slot, waiting in queue if all slots are taken.
+// `release` must be called after the page is closed.
+func (pool *BrowserPool) Acquire(ctx context.Context) (release func(), err error) {
+	release = func() { <-pool.slots }
+	select {
+	case pool.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	pool.mu.Lock()
+	if pool.waiting >= pool.queueSize {
+		pool.mu.Unlock()
+		return nil, ErrPoolBusy
+	}
+	pool.waiting++
+	pool.mu.Unlock()
+	defer func() {
+		pool.mu.Lock()
+		pool.waiting--
+		pool.mu.Unlock()
+	}()
+
+	if pool.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pool.queueTimeout)
+		defer cancel()
+	}
+	select {
+	case pool.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, xerrors.Errorf("waiting for page: %w", ctx.Err())
+	}
+}
+
+// Page opens a blank page in a new incognito context. `cleanup`
+// closes the page and the context, and releases the slot.
+func (pool *BrowserPool) Page(ctx context.Context) (page *rod.Page, cleanup func(), err error) {
+	release, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	browser := pool.current()
+	incognito, err := pool.incognito(browser)
+	if err != nil {
+		// Browser may be dead. Try once more with a new one.
+		l.Warnf("opening incognito context: %s, relaunching browser", err.Error())
+		if err := pool.relaunch(browser); err != nil {
+			release()
+			return nil, nil, err
+		}
+		if incognito, err = pool.incognito(pool.current()); err != nil {
+			release()
+			return nil, nil, xerrors.Errorf("opening incognito context: %w", err)
+		}
+	}
+
+	page, err = incognito.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		_ = incognito.Close()
+		release()
+		return nil, nil, xerrors.Errorf("opening page: %w", err)
+	}
+
+	cleanup = func() {
+		_ = page.Close()
+		_ = incognito.Close()
+		release()
+	}
+	return page, cleanup, nil
+}
+
+func (pool *BrowserPool) current() *rod.Browser {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.browser
+}
+
+func (pool *BrowserPool) incognito(browser *rod.Browser) (*rod.Browser, error) {
+	if browser == nil {
+		return nil, xerrors.New("browser not launched")
+	}
+	return browser.Incognito()
+}
+
+// Relaunch kills current browser (if any) and starts a new one.
+func (pool *BrowserPool) Relaunch() error {
+	return pool.relaunch(pool.current())
+}
+
+// relaunch replaces `failed` browser. Nothing is done if it has been
+// replaced by another caller already.
+func (pool *BrowserPool) relaunch(failed *rod.Browser) error {
+	pool.launchMu.Lock()
+	defer pool.launchMu.Unlock()
+
+	pool.mu.Lock()
+	if pool.browser != failed {
+		pool.mu.Unlock()
+		return nil
+	}
+	oldBrowser, oldLauncher := pool.browser, pool.launcher
+	pool.browser, pool.launcher = nil, nil
+	pool.healthy = false
+	pool.mu.Unlock()
+
+	if oldBrowser != nil {
+		_ = oldBrowser.Close()
+	}
+	if oldLauncher != nil {
+		oldLauncher.Kill()
+	}
+
+	browser, launcher, err := pool.launch()
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.launches++
+	if err != nil {
+		pool.lastError = err.Error()
+		return xerrors.Errorf("launching browser: %w", err)
+	}
+	pool.browser, pool.launcher = browser, launcher
+	pool.healthy = true
+	pool.lastError = ""
+	Browser = browser
+	return nil
+}
+
+// Probe checks if the browser responds, and relaunches it if not.
+func (pool *BrowserPool) Probe() error {
+	pool.mu.Lock()
+	browser := pool.browser
+	pool.lastProbeAt = time.Now()
+	pool.mu.Unlock()
+
+	if browser != nil {
+		_, err := browser.Timeout(probeTimeout).Version()
+		if err == nil {
+			pool.mu.Lock()
+			pool.healthy = true
+			pool.mu.Unlock()
+			return nil
+		}
+		l.Warnf("browser probe failed: %s, relaunching", err.Error())
+	}
+	return pool.relaunch(browser)
+}
+
+// StartProbe probes the browser every `interval` until `ctx` is done.
+func (pool *BrowserPool) StartProbe(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := pool.Probe(); err != nil {
+					l.Errorf("browser probe: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+func (pool *BrowserPool) Health() PoolHealth {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return PoolHealth{
+		MaxPages:    cap(pool.slots),
+		InUse:       len(pool.slots),
+		Waiting:     pool.waiting,
+		QueueSize:   pool.queueSize,
+		Healthy:     pool.healthy,
+		Launches:    pool.launches,
+		LastProbeAt: pool.lastProbeAt,
+		LastError:   pool.lastError,
+	}
+}
+
+func launchBrowser() (browser *rod.Browser, ln *launcher.Launcher, err error) {
+	// Launcher panics when no browser found and download failed.
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("%v", r)
+		}
+	}()
+
+	switch common.CurrentRuntime {
+	case common.Runtimes.Lambda:
+		ln = newLambdaLauncher(LauncherPath)
+	default:
+		ln = newLauncher(LauncherPath)
+	}
+
+	u, err := ln.Launch()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	browser = rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		ln.Kill()
+		return nil, nil, err
+	}
+	return browser, ln, nil
+}
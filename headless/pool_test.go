@@ -0,0 +1,61 @@
+package headless
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func Test_BrowserPool_Acquire(t *testing.T) {
+	pool := NewBrowserPool(1, 1, 500*time.Millisecond)
+
+	release, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, pool.Health().InUse)
+
+	// One request waits in queue, the next one is rejected.
+	waited := make(chan error)
+	go func() {
+		release, err := pool.Acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		waited <- err
+	}()
+	require.Eventually(t, func() bool { return pool.Health().Waiting == 1 }, time.Second, time.Millisecond)
+	_, err = pool.Acquire(context.Background())
+	require.ErrorIs(t, err, ErrPoolBusy)
+
+	release()
+	require.NoError(t, <-waited)
+	require.Equal(t, 0, pool.Health().InUse)
+
+	// Queue timeout.
+	release, err = pool.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+	_, err = pool.Acquire(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_BrowserPool_Relaunch(t *testing.T) {
+	pool := NewBrowserPool(1, 0, 0)
+	pool.launch = func() (*rod.Browser, *launcher.Launcher, error) {
+		return nil, nil, xerrors.New("chromium not found")
+	}
+
+	require.Error(t, pool.Probe())
+	_, _, err := pool.Page(context.Background())
+	require.Error(t, err)
+
+	health := pool.Health()
+	require.False(t, health.Healthy)
+	require.Equal(t, 2, health.Launches)
+	require.Equal(t, "chromium not found", health.LastError)
+	require.Equal(t, 0, health.InUse, "slot should be released on failure")
+}
@@ -0,0 +1,206 @@
+package headless
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultProfile is used by requests not giving `profile`, if
+	// configured.
+	DefaultProfile = "default"
+
+	WaitLoad        = "load"
+	WaitNetworkIdle = "network_idle"
+	WaitNone        = "none"
+)
+
+var (
+	// Profiles are loaded by `LoadProfiles`, keyed by name.
+	Profiles = map[string]*Profile{}
+
+	defaultBlockedResources = []proto.NetworkResourceType{
+		proto.NetworkResourceTypeFont,
+		proto.NetworkResourceTypeImage,
+		proto.NetworkResourceTypeMedia,
+		proto.NetworkResourceTypeStylesheet,
+		proto.NetworkResourceTypeWebSocket, // we don't need websockets to fetch html
+	}
+	validWaits = map[string]struct{}{
+		"":              {},
+		WaitLoad:        {},
+		WaitNetworkIdle: {},
+		WaitNone:        {},
+	}
+)
+
+// Profile customizes how pages are opened for some platform.
+type Profile struct {
+	// Hosts (and their subdomains) the profile opens pages of. Other
+	// locations are rejected. Required if cookies or headers are
+	// given; any host if empty.
+	Hosts []string `json:"hosts"`
+	// Cookies should be of `Hosts`.
+	Cookies []*proto.NetworkCookieParam `json:"cookies"`
+	// Headers are sent with requests to `Hosts` only, never to third
+	// parties.
+	Headers   map[string]string `json:"headers"`
+	UserAgent string            `json:"user_agent"`
+	// BlockedURLs are URL patterns (`*` for any) to block, e.g.
+	// `*://*.google-analytics.com/*`.
+	BlockedURLs []string `json:"blocked_urls"`
+	// BlockedResources are resource types (e.g. `Image`) to block.
+	// Fonts, images, media, stylesheets and websockets if not given.
+	BlockedResources []proto.NetworkResourceType `json:"blocked_resources"`
+	// Wait is what to wait for after navigation: `load` (default),
+	// `network_idle` or `none` (matches wait until timeout anyway).
+	Wait string `json:"wait"`
+
+	blockedURLs []*regexp.Regexp
+}
+
+// LoadProfiles reads profiles from JSON file at `path`, which is an
+// object keyed by profile name.
+func LoadProfiles(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return xerrors.Errorf("reading profiles: %w", err)
+	}
+	profiles := map[string]*Profile{}
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		return xerrors.Errorf("parsing profiles: %w", err)
+	}
+	for name, profile := range profiles {
+		if err := profile.init(); err != nil {
+			return xerrors.Errorf("profile %s: %w", name, err)
+		}
+	}
+	Profiles = profiles
+	return nil
+}
+
+func (profile *Profile) init() error {
+	if _, ok := validWaits[profile.Wait]; !ok {
+		return xerrors.Errorf("'wait' should be '%s', '%s' or '%s'", WaitLoad, WaitNetworkIdle, WaitNone)
+	}
+	if len(profile.Hosts) == 0 && (len(profile.Cookies) > 0 || len(profile.Headers) > 0) {
+		return xerrors.New("'hosts' is required with cookies or headers")
+	}
+	for _, cookie := range profile.Cookies {
+		host := strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+		if host == "" {
+			if u, err := url.Parse(cookie.URL); err == nil {
+				host = strings.ToLower(u.Hostname())
+			}
+		}
+		if !matchHost(host, profile.Hosts) {
+			return xerrors.Errorf("cookie %s is not of 'hosts'", cookie.Name)
+		}
+	}
+	if profile.BlockedResources == nil {
+		profile.BlockedResources = defaultBlockedResources
+	}
+	profile.blockedURLs = nil
+	for _, pattern := range profile.BlockedURLs {
+		reg, err := regexp.Compile(proto.PatternToReg(pattern))
+		if err != nil {
+			return xerrors.Errorf("blocked URL %s: %w", pattern, err)
+		}
+		profile.blockedURLs = append(profile.blockedURLs, reg)
+	}
+	return nil
+}
+
+// getProfile gives profile of `name`, `DefaultProfile` if `name` is
+// empty, or a profile with nothing customized.
+func getProfile(name string) (*Profile, error) {
+	if name == "" {
+		name = DefaultProfile
+		if _, ok := Profiles[name]; !ok {
+			return &Profile{BlockedResources: defaultBlockedResources}, nil
+		}
+	}
+	profile, ok := Profiles[name]
+	if !ok {
+		return nil, xerrors.Errorf("'profile' %s is unknown", name)
+	}
+	return profile, nil
+}
+
+// Apply sets cookies and user agent of `profile` to `page`. Must be
+// done before navigation. Headers are set per request by
+// `RequestHeaders`.
+func (profile *Profile) Apply(page *rod.Page) error {
+	if len(profile.Cookies) > 0 {
+		if err := page.SetCookies(profile.Cookies); err != nil {
+			return xerrors.Errorf("setting cookies: %w", err)
+		}
+	}
+	if profile.UserAgent != "" {
+		if err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{UserAgent: profile.UserAgent}); err != nil {
+			return xerrors.Errorf("setting user agent: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckLocation tells if `location` is of `Hosts`.
+func (profile *Profile) CheckLocation(location string) error {
+	if len(profile.Hosts) == 0 {
+		return nil
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return xerrors.Errorf("%w: %s", ErrHostBlocked, err.Error())
+	}
+	if !matchHost(strings.ToLower(u.Hostname()), profile.Hosts) {
+		return xerrors.Errorf("%w: %s is not of profile", ErrHostBlocked, u.Hostname())
+	}
+	return nil
+}
+
+// RequestHeaders gives headers of request to `u` with `Headers` of
+// `profile` added, if `u` is of `Hosts`. Nil if nothing to add.
+func (profile *Profile) RequestHeaders(u *url.URL, original proto.NetworkHeaders) []*proto.FetchHeaderEntry {
+	if len(profile.Headers) == 0 || !matchHost(strings.ToLower(u.Hostname()), profile.Hosts) {
+		return nil
+	}
+	entries := make([]*proto.FetchHeaderEntry, 0, len(original)+len(profile.Headers))
+	overridden := make(map[string]bool, len(profile.Headers))
+	for name := range profile.Headers {
+		overridden[http.CanonicalHeaderKey(name)] = true
+	}
+	for name, value := range original {
+		if overridden[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		entries = append(entries, &proto.FetchHeaderEntry{Name: name, Value: value.Str()})
+	}
+	for name, value := range profile.Headers {
+		entries = append(entries, &proto.FetchHeaderEntry{Name: name, Value: value})
+	}
+	return entries
+}
+
+// Blocks tells if request of `url` and `resourceType` should fail.
+func (profile *Profile) Blocks(url string, resourceType proto.NetworkResourceType) bool {
+	for _, blocked := range profile.BlockedResources {
+		if blocked == resourceType {
+			return true
+		}
+	}
+	for _, reg := range profile.blockedURLs {
+		if reg.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package headless
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/ysmood/gson"
+)
+
+func Test_LoadProfiles(t *testing.T) {
+	original := Profiles
+	t.Cleanup(func() { Profiles = original })
+
+	t.Run("sample", func(t *testing.T) {
+		require.NoError(t, LoadProfiles("../config/headless_profiles.sample.json"))
+
+		profile, err := getProfile("")
+		require.NoError(t, err)
+		require.Same(t, Profiles[DefaultProfile], profile)
+		require.True(t, profile.Blocks("https://www.google-analytics.com/analytics.js", proto.NetworkResourceTypeScript))
+		require.True(t, profile.Blocks("https://x.com/logo.png", proto.NetworkResourceTypeImage), "default resources")
+		require.False(t, profile.Blocks("https://x.com/", proto.NetworkResourceTypeDocument))
+
+		profile, err = getProfile("twitter")
+		require.NoError(t, err)
+		require.Equal(t, "auth_token", profile.Cookies[0].Name)
+		require.True(t, profile.Cookies[0].HTTPOnly)
+		require.False(t, profile.Blocks("https://x.com/style.css", proto.NetworkResourceTypeStylesheet))
+
+		_, err = getProfile("unknown")
+		require.ErrorContains(t, err, "'profile' unknown is unknown")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		for content, message := range map[string]string{
+			`{"a": {"wait": "forever"}}`:                          "'wait' should be",
+			`{"a": {"headers": {"Authorization": "Bearer abc"}}}`: "'hosts' is required",
+			`{"a": {"hosts": ["x.com"], "cookies": [{"name": "auth", "value": "x", "domain": ".evil.com"}]}}`: "cookie auth is not of 'hosts'",
+			`{"a": {"hosts": ["x.com"], "cookies": [{"name": "auth", "value": "x"}]}}`:                        "cookie auth is not of 'hosts'",
+		} {
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			err := LoadProfiles(path)
+			require.ErrorContains(t, err, "profile a", content)
+			require.ErrorContains(t, err, message, content)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		Profiles = map[string]*Profile{}
+		profile, err := getProfile("")
+		require.NoError(t, err)
+		require.True(t, profile.Blocks("https://x.com/logo.png", proto.NetworkResourceTypeImage))
+	})
+}
+
+func Test_Profile_RequestHeaders(t *testing.T) {
+	profile := &Profile{Hosts: []string{"x.com"}, Headers: map[string]string{"authorization": "Bearer abc"}}
+	original := proto.NetworkHeaders{"Authorization": gson.New("Basic def"), "Accept": gson.New("*/*")}
+
+	mustParse := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+	headers := func(entries []*proto.FetchHeaderEntry) map[string]string {
+		result := map[string]string{}
+		for _, entry := range entries {
+			result[entry.Name] = entry.Value
+		}
+		return result
+	}
+
+	require.Equal(t, map[string]string{"authorization": "Bearer abc", "Accept": "*/*"},
+		headers(profile.RequestHeaders(mustParse("https://x.com/home"), original)))
+	require.Equal(t, map[string]string{"authorization": "Bearer abc", "Accept": "*/*"},
+		headers(profile.RequestHeaders(mustParse("https://api.x.com/graphql"), original)), "subdomain")
+	require.Nil(t, profile.RequestHeaders(mustParse("https://evil.com/x.com"), original), "third party")
+	require.Nil(t, profile.RequestHeaders(mustParse("https://notx.com/"), original))
+	require.Nil(t, (&Profile{Hosts: []string{"x.com"}}).RequestHeaders(mustParse("https://x.com/"), original))
+}
+
+func Test_Profile_CheckLocation(t *testing.T) {
+	profile := &Profile{Hosts: []string{"x.com"}}
+	require.NoError(t, profile.CheckLocation("https://x.com/yeiwb/status/1"))
+	require.NoError(t, profile.CheckLocation("https://mobile.x.com/yeiwb/status/1"))
+	require.ErrorIs(t, profile.CheckLocation("https://attacker.com/x.com"), ErrHostBlocked)
+	require.ErrorIs(t, profile.CheckLocation("https://x.com.attacker.com/"), ErrHostBlocked)
+	require.NoError(t, (&Profile{}).CheckLocation("https://attacker.com/"), "any host without hosts")
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nextdotid/proof_server/types"
+	"golang.org/x/xerrors"
+)
+
+// IdentityRename records an identity of a proof renamed on platform,
+// found by stable `AltID` while revalidating.
+type IdentityRename struct {
+	ID        int64     `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+
+	ProofID  int64          `gorm:"column:proof_id;index;not null"`
+	Persona  string         `gorm:"index;not null"`
+	Platform types.Platform `gorm:"index;not null"`
+	AltID    string         `gorm:"column:alt_id;index"`
+	// OldIdentity is the previous name on platform.
+	OldIdentity string `gorm:"column:old_identity;index;not null"`
+	// NewIdentity is the name renamed to.
+	NewIdentity string `gorm:"column:new_identity;not null"`
+}
+
+func (IdentityRename) TableName() string {
+	return "identity_rename"
+}
+
+// FindPreviousIdentities gives previous identities of each proof,
+// oldest first.
+func FindPreviousIdentities(proofIDs []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string, len(proofIDs))
+	if len(proofIDs) == 0 {
+		return result, nil
+	}
+
+	renames := make([]IdentityRename, 0)
+	tx := ReadOnlyDB.Model(&IdentityRename{}).Where("proof_id IN ?", proofIDs).Order("id ASC").Find(&renames)
+	if tx.Error != nil {
+		return nil, xerrors.Errorf("error when finding identity renames: %w", tx.Error)
+	}
+	for _, rename := range renames {
+		result[rename.ProofID] = append(result[rename.ProofID], rename.OldIdentity)
+	}
+	return result, nil
+}
+
+// rename updates `proof.Identity` to `newIdentity` and records the
+// old one. Only proofs with `AltID` can be renamed, and changes of
+// letter case only are not renames. Proof itself is not saved here.
+func (proof *Proof) rename(newIdentity string) error {
+	if proof.AltID == "" || newIdentity == "" || strings.EqualFold(proof.Identity, newIdentity) {
+		return nil
+	}
+
+	rename := IdentityRename{
+		ProofID:     proof.ID,
+		Persona:     proof.Persona,
+		Platform:    proof.Platform,
+		AltID:       proof.AltID,
+		OldIdentity: proof.Identity,
+		NewIdentity: newIdentity,
+	}
+	if tx := DB.Create(&rename); tx.Error != nil {
+		return xerrors.Errorf("error when recording rename: %w", tx.Error)
+	}
+	proof.Identity = newIdentity
+	return nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Proof_rename(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		before_each(t)
+
+		pk, _ := crypto.GenerateSecp256k1Keypair()
+		proof := Proof{
+			Persona:  MarshalAvatar(pk),
+			Platform: types.Platforms.Github,
+			Identity: "alice",
+			AltID:    "1024",
+		}
+		require.NoError(t, DB.Create(&proof).Error)
+
+		require.NoError(t, proof.rename("Alice"))
+		require.Equal(t, "alice", proof.Identity, "letter case change is not a rename")
+
+		require.NoError(t, proof.rename("alice_new"))
+		require.NoError(t, proof.rename("alice_newer"))
+		require.Equal(t, "alice_newer", proof.Identity)
+
+		previous, err := FindPreviousIdentities([]int64{proof.ID})
+		require.NoError(t, err)
+		require.Equal(t, []string{"alice", "alice_new"}, previous[proof.ID])
+	})
+
+	t.Run("no alt ID", func(t *testing.T) {
+		before_each(t)
+
+		proof := Proof{Platform: types.Platforms.Github, Identity: "alice"}
+		require.NoError(t, proof.rename("alice_new"))
+		require.Equal(t, "alice", proof.Identity)
+	})
+}
@@ -37,6 +37,9 @@ func Init(autoMigrate bool) {
 			&ProofChain{},
 			&AvatarAlias{},
 			&Subkey{},
+			&IdentityRename{},
+			&ProofEvidence{},
+			&ProofValidityEvent{},
 		)
 		if err != nil {
 			panic(err)
@@ -12,6 +12,9 @@ func before_each(t *testing.T) {
 	DB.Where("1 = 1").Delete(&Proof{})
 	DB.Where("1 = 1").Delete(&ProofChain{})
 	DB.Where("1 = 1").Delete(&AvatarAlias{})
+	DB.Where("1 = 1").Delete(&IdentityRename{})
+	DB.Where("1 = 1").Delete(&ProofEvidence{})
+	DB.Where("1 = 1").Delete(&ProofValidityEvent{})
 }
 
 func TestMain(m *testing.M) {
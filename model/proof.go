@@ -28,6 +28,9 @@ type Proof struct {
 	Identity string         `gorm:"index;not null"`
 	AltID    string         `gorm:"column:alt_id;index"`
 	Location string         `gorm:"not null"`
+	// VerifiedOwner is true if owner of the identity (ENS name) is
+	// also bound to the persona with an `ethereum` proof.
+	VerifiedOwner bool `gorm:"column:verified_owner;not null;default:false"`
 }
 
 func (Proof) TableName() string {
@@ -52,11 +55,6 @@ func (proof *Proof) IsOutdated() bool {
 // Revalidate validates current proof, will update `IsValid` and
 // `LastCheckedAt`. Must be used after `DB.Preload("ProofChain")`.
 func (proof *Proof) Revalidate() (err error) {
-	// FIXME: platform twitter causes too many errors. Disable for now.
-	if proof.Platform == types.Platforms.Twitter {
-		return nil
-	}
-
 	v, err := proof.ProofChain.RestoreValidator()
 	if err != nil || v == nil {
 		return xerrors.Errorf("restoring validator: %w", err)
@@ -68,16 +66,43 @@ func (proof *Proof) Revalidate() (err error) {
 	}
 
 	err = iv.Validate()
+	if _, evidenceErr := CreateEvidence(v, proof.ProofChainID, proof.ID, err); evidenceErr != nil {
+		l.Warnf("proof %d: %s", proof.ID, evidenceErr.Error())
+	}
 	if err != nil {
+		proof.VerifiedOwner = false
 		proof.touchValid(err.Error(), iv.GetAltID())
 		return xerrors.Errorf("validate failed: %w", err)
 	}
 
+	proof.VerifiedOwner = ownerVerified(proof.Persona, proof.Platform, v.Extra)
+	// Validators accept a renamed user by `AltID`, giving current
+	// identity in `v.Identity`.
+	if err := proof.rename(v.Identity); err != nil {
+		l.Warnf("proof %d: %s", proof.ID, err.Error())
+	}
 	proof.touchValid("", iv.GetAltID())
-	// TODO: need to update `identity` and `alt_id` here.
 	return nil
 }
 
+// ownerVerified tells if owner recorded by validator in `extra` is a
+// wallet bound to `persona` with a valid `ethereum` proof.
+func ownerVerified(persona string, platform types.Platform, extra map[string]string) bool {
+	owner := validator.RecordedOwner(platform, extra)
+	if owner == "" {
+		return false
+	}
+	var count int64
+	tx := ReadOnlyDB.Model(&Proof{}).Where(
+		"persona = ? AND platform = ? AND identity = ? AND is_valid = ?",
+		persona, types.Platforms.Ethereum, owner, true,
+	).Count(&count)
+	if tx.Error != nil {
+		return false
+	}
+	return count > 0
+}
+
 func (proof *Proof) touchValid(reason, altID string) {
 	fmt.Printf("AltID: %s\n", altID)
 	proof.LastCheckedAt = time.Now()
@@ -88,4 +113,7 @@ func (proof *Proof) touchValid(reason, altID string) {
 	}
 
 	DB.Save(proof)
+	if err := proof.createValidityEvent(); err != nil {
+		l.Warnf("proof %d: %s", proof.ID, err.Error())
+	}
 }
@@ -67,6 +67,8 @@ type ProofChainArweaveDocument struct {
 	Extra             datatypes.JSON `json:"extra"`
 	PreviousUuid      string         `json:"previous_uuid"`
 	PreviousArweaveID string         `json:"previous_arweave_id"`
+	// Evidence is given if `arweave.include_evidence` is on.
+	Evidence *ProofEvidenceArweaveDocument `json:"evidence,omitempty"`
 }
 
 func (ProofChain) TableName() string {
@@ -120,6 +122,8 @@ func (pc *ProofChain) createProof() (err error) {
 		Identity: pc.Identity,
 		Location: pc.Location,
 	}
+	// Malformed `Extra` is treated as empty here.
+	extra, _ := pc.unmarshalExtra()
 	proof_create := &Proof{
 		ProofChainID:  pc.ID,
 		Persona:       pc.Persona,
@@ -130,6 +134,7 @@ func (pc *ProofChain) createProof() (err error) {
 		LastCheckedAt: time.Now(),
 		IsValid:       true,
 		InvalidReason: "",
+		VerifiedOwner: ownerVerified(pc.Persona, pc.Platform, extra),
 	}
 	tx := DB.FirstOrCreate(proof_create, proof_condition)
 	if tx.Error != nil {
@@ -143,13 +148,15 @@ func (pc *ProofChain) deleteProof() (err error) {
 	if pc.Platform == types.Platforms.NextID {
 		return pc.DeleteAlias()
 	}
-	tx := DB.Delete(&Proof{}, Proof{
-		Persona:  pc.Persona,
-		Platform: pc.Platform,
-		Identity: pc.Identity,
-		// Delete all bindings regardless of proof location.
-		// Location: pc.Location,
-	})
+	// Delete all bindings regardless of proof location. Identity
+	// given may be the one before renamed, or alt ID.
+	tx := DB.Where("persona = @persona AND platform = @platform", sql.Named("persona", pc.Persona), sql.Named("platform", pc.Platform)).
+		Where("identity = @identity OR alt_id IN @alt_ids OR id IN (SELECT proof_id FROM identity_rename WHERE persona = @persona AND platform = @platform AND old_identity = @identity)",
+			sql.Named("identity", pc.Identity),
+			sql.Named("alt_ids", lo.Uniq(lo.Compact([]string{pc.Identity, pc.AltID}))),
+			sql.Named("persona", pc.Persona),
+			sql.Named("platform", pc.Platform)).
+		Delete(&Proof{})
 	if tx.Error != nil {
 		return xerrors.Errorf("%w", tx.Error)
 	}
@@ -171,12 +178,9 @@ func (pc *ProofChain) RestoreValidator() (v *validator.Base, err error) {
 		previousSig = pc.Previous.Signature
 	}
 
-	extra := map[string]string{}
-	if pc.Extra.String() != "" {
-		err = json.Unmarshal([]byte(pc.Extra.String()), &extra)
-		if err != nil {
-			return nil, xerrors.Errorf("%w", err)
-		}
+	extra, err := pc.unmarshalExtra()
+	if err != nil {
+		return nil, err
 	}
 	parsedUuid, err := uuid.Parse(pc.Uuid)
 	if err != nil {
@@ -200,6 +204,17 @@ func (pc *ProofChain) RestoreValidator() (v *validator.Base, err error) {
 	return v, nil
 }
 
+func (pc *ProofChain) unmarshalExtra() (extra map[string]string, err error) {
+	extra = map[string]string{}
+	if pc.Extra.String() != "" {
+		err = json.Unmarshal([]byte(pc.Extra.String()), &extra)
+		if err != nil {
+			return nil, xerrors.Errorf("%w", err)
+		}
+	}
+	return extra, nil
+}
+
 func (pc *ProofChain) CreateAlias() (err error) {
 	if pc.Platform != types.Platforms.NextID {
 		return xerrors.New("Cannot create alias on a non-nextid ProofChain record")
@@ -311,6 +326,11 @@ func ProofChainCreateFromValidator(validator *validator.Base) (pc *ProofChain, e
 		return nil, xerrors.Errorf("%w", err)
 	}
 
+	// Evidence is nice-to-have. Do not fail the upload for it.
+	if _, err := CreateEvidence(validator, pc.ID, 0, nil); err != nil {
+		l.Warnf("proof chain %d: %s", pc.ID, err.Error())
+	}
+
 	return pc, nil
 }
 
@@ -226,6 +226,48 @@ func Test_Apply(t *testing.T) {
 		assert.Equal(t, int64(0), count)
 	})
 
+	t.Run("delete renamed", func(t *testing.T) {
+		before_each(t)
+		pk, _ := crypto.GenerateSecp256k1Keypair()
+		pc := ProofChain{
+			Action:    types.Actions.Create,
+			Persona:   MarshalAvatar(pk),
+			Identity:  "yeiwb",
+			AltID:     "1468853291941773312",
+			Location:  "1469221200140574721",
+			Platform:  types.Platforms.Twitter,
+			Signature: MarshalSignature([]byte{1}),
+		}
+		assert.Nil(t, DB.Create(&pc).Error)
+		assert.Nil(t, pc.Apply())
+
+		for _, deleted := range []ProofChain{
+			// By identity before renamed
+			{Identity: "yeiwb"},
+			// By alt ID given as identity
+			{Identity: "1468853291941773312"},
+			// By alt ID, renamed again since last validated
+			{Identity: "yeiwb_newer", AltID: "1468853291941773312"},
+		} {
+			proof := Proof{ProofChainID: pc.ID}
+			assert.Nil(t, DB.Where(&proof).First(&proof).Error)
+			assert.Nil(t, proof.rename("yeiwb_new"))
+			assert.Nil(t, DB.Save(&proof).Error)
+
+			deleted.Action = types.Actions.Delete
+			deleted.Persona = pc.Persona
+			deleted.Platform = pc.Platform
+			assert.Nil(t, deleted.Apply())
+			var count int64
+			DB.Model(&Proof{}).Where("proof_chain_id = ?", pc.ID).Count(&count)
+			assert.Equal(t, int64(0), count)
+
+			// Bound again for the next case.
+			pc.Identity = "yeiwb"
+			assert.Nil(t, pc.Apply())
+		}
+	})
+
 	t.Run("avoid duplicate", func(t *testing.T) {
 		before_each(t)
 		pk, _ := crypto.GenerateSecp256k1Keypair()
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/validator"
+	"golang.org/x/xerrors"
+)
+
+// ProofEvidence is a snapshot of proof post fetched in one validation.
+type ProofEvidence struct {
+	ID        int64     `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+
+	ProofChainID int64 `gorm:"column:proof_chain_id;index;not null"`
+	// ProofID is 0 if taken when uploading.
+	ProofID  int64          `gorm:"column:proof_id;index"`
+	Persona  string         `gorm:"index;not null"`
+	Platform types.Platform `gorm:"index;not null"`
+	Identity string         `gorm:"index;not null"`
+	Location string         `gorm:"not null"`
+
+	// ContentHash is hex-encoded SHA256 of raw content fetched.
+	ContentHash string `gorm:"column:content_hash;index;not null"`
+	// Text is normalized post text.
+	Text       string    `gorm:"not null"`
+	Author     string    `gorm:"not null;default:''"`
+	Fetcher    string    `gorm:"not null;default:''"`
+	StatusCode int       `gorm:"column:status_code;not null;default:0"`
+	FetchedAt  time.Time `gorm:"column:fetched_at"`
+	// Cached is true if given from cache of headless service.
+	Cached bool `gorm:"column:cached;not null;default:false"`
+	// ScreenshotHash and HTMLHash are hashes of page captured by
+	// headless browser.
+	ScreenshotHash string `gorm:"column:screenshot_hash;not null;default:''"`
+	HTMLHash       string `gorm:"column:html_hash;not null;default:''"`
+	IsValid        bool
+	// InvalidReason is the validation error, if any.
+	InvalidReason string
+}
+
+func (ProofEvidence) TableName() string {
+	return "proof_evidence"
+}
+
+// ProofEvidenceArweaveDocument is evidence archived along with
+// `ProofChainArweaveDocument`.
+type ProofEvidenceArweaveDocument struct {
+	ContentHash string `json:"content_hash"`
+	Text        string `json:"text"`
+	Author      string `json:"author"`
+	Fetcher     string `json:"fetcher"`
+	StatusCode  int    `json:"status_code"`
+	FetchedAt   string `json:"fetched_at"`
+	Cached      bool   `json:"cached,omitempty"`
+	// Captures themselves are kept by headless service.
+	ScreenshotHash string `json:"screenshot_hash,omitempty"`
+	HTMLHash       string `json:"html_hash,omitempty"`
+}
+
+// CreateEvidence saves what `v` fetched in its last validation, if
+// anything was fetched. `validateErr` is the result of validation.
+func CreateEvidence(v *validator.Base, proofChainID, proofID int64, validateErr error) (*ProofEvidence, error) {
+	evidence := v.GetEvidence()
+	if evidence == nil {
+		return nil, nil
+	}
+
+	record := &ProofEvidence{
+		ProofChainID:   proofChainID,
+		ProofID:        proofID,
+		Persona:        MarshalAvatar(v.Pubkey),
+		Platform:       v.Platform,
+		Identity:       v.Identity,
+		Location:       v.ProofLocation,
+		ContentHash:    evidence.ContentHash(v.Text),
+		Text:           validator.NormalizeText(v.Text),
+		Author:         evidence.Author,
+		Fetcher:        evidence.Fetcher,
+		StatusCode:     evidence.StatusCode,
+		FetchedAt:      evidence.FetchedAt,
+		Cached:         evidence.Cached,
+		ScreenshotHash: evidence.ScreenshotHash,
+		HTMLHash:       evidence.HTMLHash,
+		IsValid:        validateErr == nil,
+	}
+	if validateErr != nil {
+		record.InvalidReason = validateErr.Error()
+	}
+	if tx := DB.Create(record); tx.Error != nil {
+		return nil, xerrors.Errorf("error when saving evidence: %w", tx.Error)
+	}
+	return record, nil
+}
+
+// FindEvidenceByProofChain gives the evidence taken when `proofChainID`
+// was uploaded. Nil if none.
+func FindEvidenceByProofChain(proofChainID int64) (*ProofEvidence, error) {
+	evidences := make([]ProofEvidence, 0, 1)
+	tx := ReadOnlyDB.Model(&ProofEvidence{}).
+		Where("proof_chain_id = ? AND proof_id = 0", proofChainID).
+		Order("id ASC").
+		Limit(1).
+		Find(&evidences)
+	if tx.Error != nil {
+		return nil, xerrors.Errorf("error when finding evidence: %w", tx.Error)
+	}
+	if len(evidences) == 0 {
+		return nil, nil
+	}
+	return &evidences[0], nil
+}
+
+func (evidence *ProofEvidence) ToArweaveDocument() *ProofEvidenceArweaveDocument {
+	return &ProofEvidenceArweaveDocument{
+		ContentHash:    evidence.ContentHash,
+		Text:           evidence.Text,
+		Author:         evidence.Author,
+		Fetcher:        evidence.Fetcher,
+		StatusCode:     evidence.StatusCode,
+		FetchedAt:      strconv.FormatInt(evidence.FetchedAt.Unix(), 10),
+		Cached:         evidence.Cached,
+		ScreenshotHash: evidence.ScreenshotHash,
+		HTMLHash:       evidence.HTMLHash,
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nextdotid/proof_server/types"
@@ -10,6 +11,7 @@ import (
 	"github.com/nextdotid/proof_server/validator/twitter"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
 )
 
 func Test_Proof_Revalidate(t *testing.T) {
@@ -123,3 +125,42 @@ func Test_FindAllProofByPersona(t *testing.T) {
 		require.Contains(t, result_types, types.Platforms.Ethereum)
 	})
 }
+
+func Test_ProofChain_Apply_VerifiedOwner(t *testing.T) {
+	t.Run("ens owner bound as ethereum proof", func(t *testing.T) {
+		before_each(t)
+
+		pk, _ := crypto.GenerateSecp256k1Keypair()
+		wallet := Proof{
+			Persona:       MarshalAvatar(pk),
+			Platform:      types.Platforms.Ethereum,
+			Identity:      "0x4000000000000000000000000000000000000004",
+			LastCheckedAt: time.Now(),
+			IsValid:       true,
+		}
+		require.NoError(t, DB.Create(&wallet).Error)
+
+		for name, owner := range map[string]string{
+			"alice.eth": "0x4000000000000000000000000000000000000004",
+			"bob.eth":   "0x5000000000000000000000000000000000000005",
+		} {
+			pc := ProofChain{
+				Action:    types.Actions.Create,
+				Persona:   MarshalAvatar(pk),
+				Identity:  name,
+				Platform:  types.Platforms.ENS,
+				Signature: "sig-" + name,
+				Extra:     datatypes.JSON(`{"ens_owner":"` + owner + `"}`),
+				Uuid:      uuid.New().String(),
+			}
+			require.NoError(t, DB.Create(&pc).Error)
+			require.NoError(t, pc.Apply())
+		}
+
+		alice, bob := Proof{}, Proof{}
+		DB.Where("identity = ?", "alice.eth").Take(&alice)
+		DB.Where("identity = ?", "bob.eth").Take(&bob)
+		require.True(t, alice.VerifiedOwner)
+		require.False(t, bob.VerifiedOwner)
+	})
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"time"
+
+	"github.com/nextdotid/proof_server/types"
+	"golang.org/x/xerrors"
+)
+
+// ProofValidityEvent is the outcome of one revalidation of a proof.
+type ProofValidityEvent struct {
+	ID        int64     `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+
+	ProofID  int64          `gorm:"column:proof_id;index;not null"`
+	Persona  string         `gorm:"index;not null"`
+	Platform types.Platform `gorm:"index;not null"`
+	Identity string         `gorm:"not null"`
+
+	IsValid       bool
+	InvalidReason string
+	CheckedAt     time.Time `gorm:"column:checked_at;index"`
+}
+
+func (ProofValidityEvent) TableName() string {
+	return "proof_validity_event"
+}
+
+// ProofUptime summarizes validity history of a proof.
+type ProofUptime struct {
+	// Checks is the count of revalidations.
+	Checks int
+	// ValidChecks is the count of revalidations which succeeded.
+	ValidChecks int
+	// Ratio is the share of time being valid since proof creation,
+	// from 0 to 1.
+	Ratio float64
+	// Flaps is the count of validity changes.
+	Flaps int
+	// LastChangedAt is when validity changed last time. Zero if never.
+	LastChangedAt time.Time
+}
+
+func (proof *Proof) createValidityEvent() error {
+	event := ProofValidityEvent{
+		ProofID:       proof.ID,
+		Persona:       proof.Persona,
+		Platform:      proof.Platform,
+		Identity:      proof.Identity,
+		IsValid:       proof.IsValid,
+		InvalidReason: proof.InvalidReason,
+		CheckedAt:     proof.LastCheckedAt,
+	}
+	if tx := DB.Create(&event); tx.Error != nil {
+		return xerrors.Errorf("error when saving validity event: %w", tx.Error)
+	}
+	return nil
+}
+
+// FindAllValidityEvents gives validity timeline of a proof, oldest
+// first.
+func FindAllValidityEvents(proofID int64) ([]ProofValidityEvent, error) {
+	events := make([]ProofValidityEvent, 0)
+	tx := ReadOnlyDB.Model(&ProofValidityEvent{}).Where("proof_id = ?", proofID).Order("checked_at ASC, id ASC").Find(&events)
+	if tx.Error != nil {
+		return nil, xerrors.Errorf("error when finding validity events: %w", tx.Error)
+	}
+	return events, nil
+}
+
+// SummarizeUptime gives uptime of each proof.
+func SummarizeUptime(proofs []Proof) (map[int64]ProofUptime, error) {
+	result := make(map[int64]ProofUptime, len(proofs))
+	if len(proofs) == 0 {
+		return result, nil
+	}
+	proofIDs := make([]int64, 0, len(proofs))
+	for _, proof := range proofs {
+		proofIDs = append(proofIDs, proof.ID)
+	}
+
+	events := make([]ProofValidityEvent, 0)
+	tx := ReadOnlyDB.Model(&ProofValidityEvent{}).
+		Select("proof_id", "is_valid", "checked_at").
+		Where("proof_id IN ?", proofIDs).
+		Order("checked_at ASC, id ASC").
+		Find(&events)
+	if tx.Error != nil {
+		return nil, xerrors.Errorf("error when finding validity events: %w", tx.Error)
+	}
+	eventsOfProof := make(map[int64][]ProofValidityEvent, len(proofs))
+	for _, event := range events {
+		eventsOfProof[event.ProofID] = append(eventsOfProof[event.ProofID], event)
+	}
+
+	now := time.Now()
+	for _, proof := range proofs {
+		result[proof.ID] = summarizeUptime(proof.CreatedAt, eventsOfProof[proof.ID], now)
+	}
+	return result, nil
+}
+
+// summarizeUptime assumes a proof is valid since `createdAt` (it
+// was validated when uploaded), and keeps the validity of each event
+// until the next one.
+func summarizeUptime(createdAt time.Time, events []ProofValidityEvent, now time.Time) ProofUptime {
+	uptime := ProofUptime{Ratio: 1}
+	valid := true
+	since := createdAt
+	var validDuration time.Duration
+	for _, event := range events {
+		uptime.Checks++
+		if event.IsValid {
+			uptime.ValidChecks++
+		}
+		if event.CheckedAt.After(since) {
+			if valid {
+				validDuration += event.CheckedAt.Sub(since)
+			}
+			since = event.CheckedAt
+		}
+		if event.IsValid != valid {
+			uptime.Flaps++
+			uptime.LastChangedAt = event.CheckedAt
+			valid = event.IsValid
+		}
+	}
+	if now.After(since) && valid {
+		validDuration += now.Sub(since)
+	}
+	if total := now.Sub(createdAt); total > 0 {
+		uptime.Ratio = float64(validDuration) / float64(total)
+	} else if !valid {
+		uptime.Ratio = 0
+	}
+	return uptime
+}
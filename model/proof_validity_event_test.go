@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_summarizeUptime(t *testing.T) {
+	createdAt := time.Unix(1700000000, 0)
+	day := 24 * time.Hour
+	now := createdAt.Add(10 * day)
+
+	t.Run("never checked", func(t *testing.T) {
+		uptime := summarizeUptime(createdAt, nil, now)
+		require.Equal(t, 0, uptime.Checks)
+		require.Equal(t, 1.0, uptime.Ratio)
+		require.True(t, uptime.LastChangedAt.IsZero())
+	})
+
+	t.Run("flapping", func(t *testing.T) {
+		events := []ProofValidityEvent{
+			{IsValid: true, CheckedAt: createdAt.Add(2 * day)},
+			{IsValid: false, CheckedAt: createdAt.Add(4 * day)},
+			{IsValid: false, CheckedAt: createdAt.Add(5 * day)},
+			{IsValid: true, CheckedAt: createdAt.Add(7 * day)},
+		}
+		uptime := summarizeUptime(createdAt, events, now)
+		require.Equal(t, 4, uptime.Checks)
+		require.Equal(t, 2, uptime.ValidChecks)
+		require.Equal(t, 2, uptime.Flaps)
+		require.Equal(t, createdAt.Add(7*day), uptime.LastChangedAt)
+		// Invalid from day 4 to day 7.
+		require.InDelta(t, 0.7, uptime.Ratio, 1e-9)
+	})
+}
+
+func Test_Proof_touchValid_event(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		before_each(t)
+
+		proof := Proof{Persona: "0xabc", Platform: "twitter", Identity: "yeiwb", IsValid: true}
+		require.NoError(t, DB.Create(&proof).Error)
+		proof.touchValid("tweet not found", "")
+		proof.touchValid("", "")
+
+		events, err := FindAllValidityEvents(proof.ID)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(events))
+		require.False(t, events[0].IsValid)
+		require.Equal(t, "tweet not found", events[0].InvalidReason)
+		require.True(t, events[1].IsValid)
+	})
+}
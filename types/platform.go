@@ -20,6 +20,14 @@ var Platforms = struct {
 	Steam       Platform
 	ActivityPub Platform
 	Slack       Platform
+	GitLab      Platform
+	Gitea       Platform
+	PGP         Platform
+	Email       Platform
+	Reddit      Platform
+	YouTube     Platform
+	Matrix      Platform
+	SNS         Platform
 }{
 	Github:      "github",
 	NextID:      "nextid",
@@ -37,4 +45,12 @@ var Platforms = struct {
 	Steam:       "steam",
 	ActivityPub: "activitypub",
 	Slack:       "slack",
+	GitLab:      "gitlab",
+	Gitea:       "gitea",
+	PGP:         "pgp",
+	Email:       "email",
+	Reddit:      "reddit",
+	YouTube:     "youtube",
+	Matrix:      "matrix",
+	SNS:         "sns",
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/nextdotid/proof_server/util"
 	"github.com/nextdotid/proof_server/util/crypto"
 	"github.com/nextdotid/proof_server/validator"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 )
@@ -59,6 +59,11 @@ func Init() {
 		ap := ActivityPub{base}
 		return &ap
 	}
+	// Identity is kept as given, and alt ID is actor URL.
+	validator.IdentityNormalizers[types.Platforms.ActivityPub] = func(identity string) []string {
+		identity = strings.Trim(identity, "@")
+		return lo.Uniq([]string{identity, strings.ToLower(identity)})
+	}
 }
 
 func (ap *ActivityPub) SplitID() (username, server string, err error) {
@@ -82,7 +87,7 @@ func (ap *ActivityPub) DetectServerSoftware() (server ServerSoftware, err error)
 		return "", e(err)
 	}
 	// Get NodeInfo
-	resp, err := http.Get(fmt.Sprintf("https://%s/.well-known/nodeinfo", serverURL))
+	resp, err := httpClient.Get(fmt.Sprintf("https://%s/.well-known/nodeinfo", serverURL))
 	if err != nil {
 		return "", e(err)
 	}
@@ -101,7 +106,7 @@ func (ap *ActivityPub) DetectServerSoftware() (server ServerSoftware, err error)
 		if link.Rel != "http://nodeinfo.diaspora.software/ns/schema/2.0" {
 			continue
 		}
-		resp, err := http.Get(link.Href)
+		resp, err := httpClient.Get(link.Href)
 		if err != nil {
 			return "", e(err)
 		}
@@ -172,19 +177,13 @@ func (ap *ActivityPub) GenerateSignPayload() (payload string) {
 
 func (ap *ActivityPub) Validate() (err error) {
 	// Get Text
-	server, err := ap.DetectServerSoftware()
-	if err != nil {
-		return err
-	}
-	switch server {
-	case Servers.Mastodon:
-	case Servers.Pleroma:
-		err = ap.GetMastodonText()
-	case Servers.Misskey:
-		err = ap.GetMisskeyText()
-	}
-	if err != nil {
-		return err
+	if err = ap.GetObjectText(); err != nil {
+		// Fallback to server-specific API, which accepts ID-ish string
+		// in `ProofLocation` as well.
+		l.Debugf("generic object fetch failed, trying server-specific API: %s", err.Error())
+		if fallbackErr := ap.getServerSpecificText(); fallbackErr != nil {
+			return xerrors.Errorf("%s; %w", err.Error(), fallbackErr)
+		}
 	}
 	// Extract signature from text
 	if err = ap.ExtractSignature(); err != nil {
@@ -194,6 +193,21 @@ func (ap *ActivityPub) Validate() (err error) {
 	return crypto.ValidatePersonalSignature(ap.GenerateSignPayload(), ap.Signature, ap.Pubkey)
 }
 
+func (ap *ActivityPub) getServerSpecificText() (err error) {
+	server, err := ap.DetectServerSoftware()
+	if err != nil {
+		return err
+	}
+	switch server {
+	case Servers.Mastodon, Servers.Pleroma:
+		return ap.GetMastodonText()
+	case Servers.Misskey:
+		return ap.GetMisskeyText()
+	default:
+		return xerrors.Errorf("unsupported server: %s", server)
+	}
+}
+
 func (ap *ActivityPub) GetAltID() (altID string) {
 	return ap.AltID
 }
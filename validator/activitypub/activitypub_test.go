@@ -45,6 +45,6 @@ func Test_Validate(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		ap := GenerateMisskeyRecord()
 		require.NoError(t, ap.Validate())
-		require.Equal(t, ap.AltID, "8zwtspqtym")
+		require.Equal(t, "https://t.nyk.app/users/8zwtspqtym", ap.AltID)
 	})
 }
@@ -0,0 +1,132 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nextdotid/proof_server/config"
+	"golang.org/x/xerrors"
+)
+
+// SIGNED_HEADERS are covered by HTTP Signatures of outgoing GET requests.
+var SIGNED_HEADERS = []string{"(request-target)", "host", "date"}
+
+// ActorKeyID is the `keyId` of application actor's public key.
+func ActorKeyID() string {
+	return config.C.Platform.ActivityPub.ActorID + "#main-key"
+}
+
+// ActorKey parses configured private key of application actor. Gives
+// `nil` if signed fetch is not configured.
+func ActorKey() (*rsa.PrivateKey, error) {
+	apConfig := config.C.Platform.ActivityPub
+	if apConfig.ActorID == "" || apConfig.PrivateKey == "" {
+		return nil, nil
+	}
+	block, _ := pem.Decode([]byte(apConfig.PrivateKey))
+	if block == nil {
+		return nil, xerrors.New("error when parsing actor private key: PEM block not found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, xerrors.Errorf("error when parsing actor private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, xerrors.New("error when parsing actor private key: not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// ActorDocument renders application actor, which holds the public key
+// to verify HTTP Signatures made by proof service.
+func ActorDocument() (map[string]any, error) {
+	key, err := ActorKey()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, xerrors.New("application actor is not configured")
+	}
+	pubkeyBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	actorID := config.C.Platform.ActivityPub.ActorID
+	return map[string]any{
+		"@context": []string{
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/v1",
+		},
+		"id":                actorID,
+		"type":              "Application",
+		"preferredUsername": "proof_service",
+		"name":              "Next.ID Proof Service",
+		"inbox":             actorID + "/inbox",
+		"publicKey": map[string]string{
+			"id":           ActorKeyID(),
+			"owner":        actorID,
+			"publicKeyPem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubkeyBytes})),
+		},
+	}, nil
+}
+
+// SignRequest adds `Date` and `Signature` headers to a bodiless
+// request, following draft-cavage-http-signatures-12 with
+// `rsa-sha256` algorithm. Does nothing if signed fetch is not
+// configured.
+func SignRequest(req *http.Request) error {
+	key, err := ActorKey()
+	if err != nil || key == nil {
+		return err
+	}
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+
+	digest := sha256.Sum256([]byte(signingString(req, SIGNED_HEADERS)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return xerrors.Errorf("error when signing request: %w", err)
+	}
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		ActorKeyID(),
+		strings.Join(SIGNED_HEADERS, " "),
+		base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// signingString builds the string to sign from given headers.
+// https://datatracker.ietf.org/doc/html/draft-cavage-http-signatures-12#section-2.3
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines = append(lines, fmt.Sprintf("(request-target): %s %s", strings.ToLower(req.Method), req.URL.RequestURI()))
+		case "host":
+			host := req.Host
+			if host == "" {
+				host = req.URL.Host
+			}
+			lines = append(lines, "host: "+host)
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s", header, req.Header.Get(header)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
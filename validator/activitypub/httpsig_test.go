@@ -0,0 +1,66 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/stretchr/testify/require"
+)
+
+func configureActor(t *testing.T) func() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	config.C.Platform.ActivityPub = config.ActivityPubPlatformConfig{
+		ActorID:    "https://proof-service.example.com/actor",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+	}
+	return func() { config.C.Platform.ActivityPub = config.ActivityPubPlatformConfig{} }
+}
+
+func Test_SignRequest(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/notes/1", nil)
+		require.NoError(t, SignRequest(req))
+		require.Empty(t, req.Header.Get("Signature"))
+
+		_, err := ActorDocument()
+		require.Error(t, err)
+	})
+
+	t.Run("verifiable with public key in actor document", func(t *testing.T) {
+		defer configureActor(t)()
+
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/notes/1?page=2", nil)
+		require.NoError(t, SignRequest(req))
+		require.NotEmpty(t, req.Header.Get("Date"))
+
+		matched := regexp.MustCompile(`^keyId="([^"]+)",algorithm="rsa-sha256",headers="\(request-target\) host date",signature="([^"]+)"$`).
+			FindStringSubmatch(req.Header.Get("Signature"))
+		require.Len(t, matched, 3)
+		require.Equal(t, "https://proof-service.example.com/actor#main-key", matched[1])
+
+		document, err := ActorDocument()
+		require.NoError(t, err)
+		require.Equal(t, "Application", document["type"])
+		publicKey := document["publicKey"].(map[string]string)
+		require.Equal(t, matched[1], publicKey["id"])
+		block, _ := pem.Decode([]byte(publicKey["publicKeyPem"]))
+		pubkey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		require.NoError(t, err)
+
+		signature, err := base64.StdEncoding.DecodeString(matched[2])
+		require.NoError(t, err)
+		expected := "(request-target): get /notes/1?page=2\nhost: example.com\ndate: " + req.Header.Get("Date")
+		digest := sha256.Sum256([]byte(expected))
+		require.NoError(t, rsa.VerifyPKCS1v15(pubkey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature))
+	})
+}
@@ -3,7 +3,7 @@ package activitypub
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 
 	"golang.org/x/xerrors"
 )
@@ -28,22 +28,33 @@ func (ap *ActivityPub) GetMastodonText() (err error) {
 	if err != nil {
 		return err
 	}
-	resp, err := http.Get(fmt.Sprintf(MASTODON_API_STATUS, server, ap.ProofLocation))
+	resp, err := httpClient.Get(fmt.Sprintf(MASTODON_API_STATUS, server, ap.ProofLocation))
 	if err != nil {
 		return xerrors.Errorf("failed to get mastodon / pleroma status: %w", err)
 	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return xerrors.Errorf("failed to read mastodon / pleroma status: %w", err)
+	}
+	ap.RecordEvidence(body, "", FETCHER_MASTODON, resp.StatusCode)
 	var response MastodonResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	err = json.Unmarshal(body, &response)
 	if err != nil {
 		return xerrors.Errorf("failed to decode mastodon / pleroma status: %w", err)
 	}
 
-	postIdentity := fmt.Sprintf("@%s@%s", response.Account.Username, server)
+	postIdentity := fmt.Sprintf("%s@%s", response.Account.Username, server)
+	ap.Evidence.Author = postIdentity
 	if postIdentity != ap.Identity {
 		return xerrors.Errorf("failed to identify mastodon / pleroma status: identity mismatch: %s != %s", postIdentity, ap.Identity)
 	}
 
-	ap.AltID = response.Account.Id
-	ap.Text = response.Content
+	// Same as object fetched from any server: actor URL, rather than
+	// server-local account ID.
+	if ap.AltID, err = ap.ResolveActor(); err != nil {
+		return err
+	}
+	ap.Text = StripHTML(response.Content)
 	return nil
 }
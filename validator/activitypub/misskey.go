@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 
 	"golang.org/x/xerrors"
 )
@@ -38,21 +38,32 @@ func (ap *ActivityPub) GetMisskeyText() (err error) {
 	if err != nil {
 		return err
 	}
-	resp, err := http.Post(fmt.Sprintf("https://%s/api/notes/show", server), "application/json", bytes.NewReader(bodyBytes))
+	resp, err := httpClient.Post(fmt.Sprintf("https://%s/api/notes/show", server), "application/json", bytes.NewReader(bodyBytes))
 	if err != nil {
 		return xerrors.Errorf("error when fetching Misskey note: %w", err)
 	}
+	defer resp.Body.Close()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return xerrors.Errorf("error when reading Misskey note: %w", err)
+	}
+	ap.RecordEvidence(respBytes, "", FETCHER_MISSKEY, resp.StatusCode)
 	var response misskeyNotesShowResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	err = json.Unmarshal(respBytes, &response)
 	if err != nil {
 		return xerrors.Errorf("error when decoding Misskey note response: %w", err)
 	}
 	postIdentity := fmt.Sprintf("%s@%s", response.User.Username, server)
+	ap.Evidence.Author = postIdentity
 	if postIdentity != ap.Identity {
 		return xerrors.Errorf("Error when fetching Misskey note: This post is made by %s, not %s", postIdentity, ap.Identity)
 	}
 
-	ap.AltID = response.User.Id
+	// Same as object fetched from any server: actor URL, rather than
+	// server-local user ID.
+	if ap.AltID, err = ap.ResolveActor(); err != nil {
+		return err
+	}
 	ap.Text = response.Text
 	return nil
 }
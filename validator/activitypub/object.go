@@ -0,0 +1,227 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	WEBFINGER_TEMPLATE = "https://%s/.well-known/webfinger?resource=%s"
+	ACCEPT_ACTIVITY    = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+	// Fetchers of evidence.
+	FETCHER_OBJECT   = "object"
+	FETCHER_MASTODON = "mastodon"
+	FETCHER_MISSKEY  = "misskey"
+)
+
+var (
+	// httpClient is used for all requests to ActivityPub servers.
+	// Replaceable for testing.
+	httpClient = http.DefaultClient
+
+	reLineBreakTag = regexp.MustCompile(`(?i)<br\s*/?>|</p>\s*<p[^>]*>`)
+	reTag          = regexp.MustCompile(`<[^>]*>`)
+)
+
+// https://www.rfc-editor.org/rfc/rfc7033#section-4.4
+type webfingerResponse struct {
+	Subject string `json:"subject"`
+	Links   []struct {
+		Rel  string `json:"rel"`
+		Type string `json:"type"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+// Object is the subset of an ActivityStreams object used by validator.
+type Object struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	AttributedTo json.RawMessage `json:"attributedTo"`
+	Content      string          `json:"content"`
+	// Wrapped object of an activity (e.g. `Create`).
+	Object json.RawMessage `json:"object"`
+}
+
+// ResolveActor finds actor ID of `Identity` using WebFinger.
+func (ap *ActivityPub) ResolveActor() (actorID string, err error) {
+	username, server, err := ap.SplitID()
+	if err != nil {
+		return "", err
+	}
+	resource := url.QueryEscape(fmt.Sprintf("acct:%s@%s", username, server))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(WEBFINGER_TEMPLATE, server, resource), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/jrd+json, application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", xerrors.Errorf("error when requesting webfinger: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", xerrors.Errorf("error when requesting webfinger: status code %d", resp.StatusCode)
+	}
+
+	finger := webfingerResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&finger); err != nil {
+		return "", xerrors.Errorf("error when decoding webfinger response: %w", err)
+	}
+	for _, link := range finger.Links {
+		if link.Rel == "self" && isActivityContentType(link.Type) {
+			return link.Href, nil
+		}
+	}
+	return "", xerrors.Errorf("actor of %s not found in webfinger response", ap.Identity)
+}
+
+// GetObjectText fetches the object given as `ProofLocation` (an URL)
+// from any ActivityPub server, and makes sure it is attributed to the
+// actor of `Identity`.
+func (ap *ActivityPub) GetObjectText() (err error) {
+	location, err := url.Parse(ap.ProofLocation)
+	if err != nil || (location.Scheme != "https" && location.Scheme != "http") {
+		return xerrors.Errorf("proof location is not an object URL: %s", ap.ProofLocation)
+	}
+	actorID, err := ap.ResolveActor()
+	if err != nil {
+		return err
+	}
+
+	object, raw, statusCode, err := FetchObject(location.String())
+	if statusCode != 0 {
+		ap.RecordEvidence(raw, "", FETCHER_OBJECT, statusCode)
+	}
+	if err != nil {
+		return err
+	}
+	// Fetched from a server other than object's origin (e.g. a
+	// cached copy). Only trust the origin.
+	if !sameOrigin(object.ID, location.String()) {
+		object, raw, statusCode, err = FetchObject(object.ID)
+		if statusCode != 0 {
+			ap.RecordEvidence(raw, "", FETCHER_OBJECT, statusCode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !sameOrigin(object.ID, actorID) {
+		return xerrors.Errorf("object %s is not from the server of actor %s", object.ID, actorID)
+	}
+	if !object.attributedTo(actorID) {
+		return xerrors.Errorf("object %s is not attributed to %s", object.ID, actorID)
+	}
+
+	ap.AltID = actorID
+	ap.Text = StripHTML(object.Content)
+	ap.Evidence.Author = actorID
+	return nil
+}
+
+// FetchObject GETs an ActivityStreams object. Activity wrapping an
+// object (e.g. `Create`) will be unwrapped. Body and status code of
+// the response are given as well, even if it fails.
+func FetchObject(objectURL string) (object *Object, raw []byte, statusCode int, err error) {
+	req, err := http.NewRequest(http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	req.Header.Set("Accept", ACCEPT_ACTIVITY)
+	// Required by servers running in "secure mode" (authorized fetch).
+	if err := SignRequest(req); err != nil {
+		return nil, nil, 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, 0, xerrors.Errorf("error when fetching object: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, resp.StatusCode, xerrors.Errorf("error when reading object: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, raw, resp.StatusCode, xerrors.Errorf("error when fetching object: status code %d", resp.StatusCode)
+	}
+	if !isActivityContentType(resp.Header.Get("Content-Type")) {
+		return nil, raw, resp.StatusCode, xerrors.Errorf("error when fetching object: unexpected content type %s", resp.Header.Get("Content-Type"))
+	}
+
+	object = new(Object)
+	if err := json.Unmarshal(raw, object); err != nil {
+		return nil, raw, resp.StatusCode, xerrors.Errorf("error when decoding object: %w", err)
+	}
+	if len(object.Object) > 0 && object.Object[0] == '{' {
+		inner := new(Object)
+		if err := json.Unmarshal(object.Object, inner); err != nil {
+			return nil, raw, resp.StatusCode, xerrors.Errorf("error when decoding object: %w", err)
+		}
+		object = inner
+	}
+	if object.ID == "" {
+		return nil, raw, resp.StatusCode, xerrors.New("error when fetching object: object ID not found")
+	}
+	return object, raw, resp.StatusCode, nil
+}
+
+// attributedTo checks if `actorID` is in `attributedTo`, which could
+// be a string, an object, or an array of them.
+func (object *Object) attributedTo(actorID string) bool {
+	var single interface{}
+	if err := json.Unmarshal(object.AttributedTo, &single); err != nil {
+		return false
+	}
+	items, ok := single.([]interface{})
+	if !ok {
+		items = []interface{}{single}
+	}
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			if v == actorID {
+				return true
+			}
+		case map[string]interface{}:
+			if id, _ := v["id"].(string); id == actorID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StripHTML converts HTML `content` of an object into plain text,
+// keeping line breaks.
+func StripHTML(content string) string {
+	text := reLineBreakTag.ReplaceAllString(content, "\n")
+	text = reTag.ReplaceAllString(text, "")
+	return html.UnescapeString(text)
+}
+
+func isActivityContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/activity+json") ||
+		(strings.HasPrefix(contentType, "application/ld+json") && strings.Contains(contentType, "activitystreams"))
+}
+
+func sameOrigin(a, b string) bool {
+	urlA, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	urlB, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return urlA.Scheme == urlB.Scheme && strings.EqualFold(urlA.Host, urlB.Host)
+}
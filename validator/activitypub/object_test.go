@@ -0,0 +1,137 @@
+package activitypub
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+// mockInstance serves WebFinger of `alice`, and a Note at
+// `/notes/1` attributed to `attributedTo` (relative to server URL),
+// which is also given by Mastodon and Misskey API as note `1`.
+func mockInstance(t *testing.T, sk *ecdsa.PrivateKey, attributedTo string) (ap *ActivityPub, close func()) {
+	mux := http.NewServeMux()
+	ts := httptest.NewTLSServer(mux)
+	original := httpClient
+	httpClient = ts.Client()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	ca, _ := util.TimestampStringToTime("1671356397")
+	ap = &ActivityPub{
+		Base: &validator.Base{
+			Platform:      types.Platforms.ActivityPub,
+			Action:        types.Actions.Create,
+			Pubkey:        &sk.PublicKey,
+			Identity:      "alice@" + host,
+			ProofLocation: ts.URL + "/notes/1",
+			CreatedAt:     ca,
+			Uuid:          uuid.MustParse("4d89b36a-4e55-4c1f-93c8-c81b08f71b09"),
+		},
+	}
+	sig, err := crypto.SignPersonal([]byte(ap.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	text := strings.ReplaceAll(ap.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+	content := "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n", "<br />") + "</p>"
+
+	mux.HandleFunc("/.well-known/webfinger", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("resource") != "acct:alice@"+host {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/jrd+json")
+		fmt.Fprintf(w, `{"subject":"acct:alice@%s","links":[{"rel":"self","type":"application/activity+json","href":"%s/users/alice"}]}`, host, ts.URL)
+	})
+	mux.HandleFunc("/notes/1", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "application/activity+json") {
+			w.Write([]byte("<html></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/activity+json; charset=utf-8")
+		fmt.Fprintf(w, `{"id":"%s/notes/1","type":"Note","attributedTo":"%s%s","content":%q}`, ts.URL, ts.URL, attributedTo, content)
+	})
+	mux.HandleFunc("/api/v1/statuses/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"account":{"username":"alice","id":"109"},"content":%q}`, content)
+	})
+	mux.HandleFunc("/api/notes/show", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"user":{"username":"alice","id":"8zwtspqtym"},"text":%q}`, text)
+	})
+	return ap, func() {
+		ts.Close()
+		httpClient = original
+	}
+}
+
+func Test_StripHTML(t *testing.T) {
+	require.Equal(t,
+		"Hello &\nSignature: abc=\nbye",
+		StripHTML(`<p>Hello &amp;<br>Signature: <span class="x">abc=</span></p><p>bye</p>`),
+	)
+}
+
+func Test_GetObjectText(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ap, close := mockInstance(t, sk, "/users/alice")
+		defer close()
+
+		require.NoError(t, ap.Validate())
+		require.True(t, strings.HasSuffix(ap.AltID, "/users/alice"))
+		require.NotContains(t, ap.Text, "<br")
+		require.Equal(t, FETCHER_OBJECT, ap.Evidence.Fetcher)
+		require.Equal(t, ap.AltID, ap.Evidence.Author)
+		require.Equal(t, http.StatusOK, ap.Evidence.StatusCode)
+	})
+
+	t.Run("attributed to another actor", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ap, close := mockInstance(t, sk, "/users/bob")
+		defer close()
+
+		err := ap.GetObjectText()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not attributed to")
+	})
+
+	t.Run("not an URL", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ap, close := mockInstance(t, sk, "/users/alice")
+		defer close()
+
+		ap.ProofLocation = "98wr1tkc82"
+		require.Error(t, ap.GetObjectText())
+	})
+}
+
+func Test_GetServerSpecificText(t *testing.T) {
+	t.Run("Mastodon", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ap, close := mockInstance(t, sk, "/users/alice")
+		defer close()
+
+		ap.ProofLocation = "1"
+		require.NoError(t, ap.GetMastodonText())
+		require.Equal(t, "https://"+strings.Split(ap.Identity, "@")[1]+"/users/alice", ap.AltID)
+	})
+
+	t.Run("Misskey", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ap, close := mockInstance(t, sk, "/users/alice")
+		defer close()
+
+		ap.ProofLocation = "1"
+		require.NoError(t, ap.GetMisskeyText())
+		require.Equal(t, "https://"+strings.Split(ap.Identity, "@")[1]+"/users/alice", ap.AltID)
+	})
+}
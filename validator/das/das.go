@@ -114,14 +114,15 @@ func (das *Das) Validate() (err error) {
 	if err != nil {
 		return xerrors.Errorf("Error when requesting proof: %s", err.Error())
 	}
-	if resp.StatusCode != 200 {
-		return xerrors.Errorf("Error when requesting proof: Status code %d", resp.StatusCode)
-	}
-
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return xerrors.New("Error when getting resp body")
 	}
+	das.RecordEvidence(body, das.Identity, "", resp.StatusCode)
+	if resp.StatusCode != 200 {
+		return xerrors.Errorf("Error when requesting proof: Status code %d", resp.StatusCode)
+	}
 
 	result := new(DasResponse)
 	err = json.Unmarshal(body, result)
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
 	"regexp"
@@ -12,6 +13,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/nextdotid/proof_server/config"
 	"github.com/nextdotid/proof_server/util"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/xerrors"
 
 	"github.com/nextdotid/proof_server/types"
@@ -24,18 +26,42 @@ type Discord struct {
 }
 
 var (
+	l             = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "discord"})
 	re            = regexp.MustCompile(MATCH_TEMPLATE)
+	reSnowflake   = regexp.MustCompile(`^\d+$`)
 	POST_TEMPLATE = map[string]string{
 		"default": "Verifying my discord ID: %s on NextID. \nSig: %%SIG_BASE64%%",
 		"en-US":   "Verifying my discord ID: %s on NextID. \nSig: %%SIG_BASE64%%",
 		"zh-CN":   "在NextID上认证我的账号： %s \nSig: %%SIG_BASE64%%",
 	}
+
+	// fetchMessage gets a message with bot tokens from pool, along
+	// with body and status code of the response. Replaceable for
+	// testing.
+	fetchMessage = func(channelID, messageID string) (msg *discordgo.Message, raw []byte, statusCode int, err error) {
+		discordConfig := config.C.Platform.Discord
+		pool := validator.GetTokenPool(types.Platforms.Discord, append([]string{discordConfig.BotToken}, discordConfig.BotTokens...))
+		// Authorization is set by transport with token from pool.
+		client, err := discordgo.New("")
+		if err != nil {
+			return nil, nil, 0, xerrors.Errorf("Error creating Discord session: %w", err)
+		}
+		recorder := &validator.ResponseRecorder{Base: &validator.TokenTransport{Pool: pool, Authorize: authorizeBot}}
+		client.Client = &http.Client{Transport: recorder, Timeout: client.Client.Timeout}
+		msg, err = client.ChannelMessage(channelID, messageID)
+		raw, statusCode = recorder.Last()
+		return msg, raw, statusCode, err
+	}
 )
 
 const (
 	MATCH_TEMPLATE = "^Sig: (.*)$"
 )
 
+func authorizeBot(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bot "+token)
+}
+
 func Init() {
 	if validator.PlatformFactories == nil {
 		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
@@ -55,6 +81,7 @@ func (dc *Discord) GeneratePostPayload() (post map[string]string) {
 }
 
 func (dc *Discord) GenerateSignPayload() (payload string) {
+	dc.Identity = NormalizeIdentity(dc.Identity)
 	payloadStruct := validator.H{
 		"action":     string(dc.Action),
 		"identity":   dc.Identity,
@@ -80,38 +107,113 @@ func (dc *Discord) Validate() (err error) {
 		return crypto.ValidatePersonalSignature(dc.SignaturePayload, dc.Signature, dc.Pubkey)
 	}
 
-	u, err := url.Parse(dc.ProofLocation)
-	urlPath := path.Clean(u.Path)
-	pathArr := strings.Split(strings.TrimSpace(urlPath), "/")
-
-	//proof location will be like: https://discord.com/channels/960708146706395176/960708146706395179/961458176719487076
-	if len(pathArr) != 5 {
-		return xerrors.Errorf("Error getting right proof location: %w", err)
-	}
-
-	client, err := discordgo.New("Bot " + config.C.Platform.Discord.BotToken)
+	channelID, messageID, err := ParseMessageLink(dc.ProofLocation)
 	if err != nil {
-		return xerrors.Errorf("Error creating Discord session: %w", err)
+		return err
 	}
-
-	msgResp, err := client.ChannelMessage(pathArr[3], pathArr[4])
+	msgResp, raw, statusCode, err := fetchMessage(channelID, messageID)
 	if err != nil {
+		if statusCode != 0 {
+			dc.RecordEvidence(raw, "", "", statusCode)
+		}
 		return xerrors.Errorf("Error getting the message from discord: %w", err)
 	}
+	dc.RecordEvidence(raw, UserIdentity(msgResp.Author), "", statusCode)
 
-	if fmt.Sprintf("%s", msgResp.Author) != dc.Identity {
-		return xerrors.Errorf("User name mismatch: expect %s - actual %s", dc.Identity, msgResp.Author)
+	renamed := false
+	if !MatchUser(dc.Identity, msgResp.Author) {
+		// Known user (by snowflake ID) with a new name.
+		if dc.AltID == "" || dc.AltID != msgResp.Author.ID {
+			return xerrors.Errorf("User name mismatch: expect %s - actual %s", dc.Identity, UserIdentity(msgResp.Author))
+		}
+		renamed = true
 	}
 
 	dc.AltID = msgResp.Author.ID
 	dc.Text = msgResp.Content
-	return dc.validateText()
+	if err := dc.validateText(); err != nil {
+		return err
+	}
+	if renamed {
+		l.Infof("user %s renamed from %s to %s", dc.AltID, dc.Identity, UserIdentity(msgResp.Author))
+		dc.Identity = UserIdentity(msgResp.Author)
+	}
+	return nil
 }
 
 func (dc *Discord) GetAltID() string {
 	return dc.AltID
 }
 
+// NormalizeIdentity trims `identity` and the `@` before it. Unique
+// usernames (no discriminator) are lowercase. Legacy tags (`Name#1234`)
+// are kept as-is, since signatures of existing proofs contain them.
+func NormalizeIdentity(identity string) string {
+	identity = strings.TrimPrefix(strings.TrimSpace(identity), "@")
+	if !strings.Contains(identity, "#") {
+		return strings.ToLower(identity)
+	}
+	return identity
+}
+
+// UserIdentity gives the normalized identity of a Discord user: legacy
+// tag if the user still has a discriminator, or unique username.
+func UserIdentity(user *discordgo.User) string {
+	if user.Discriminator == "" || user.Discriminator == "0" {
+		return NormalizeIdentity(user.Username)
+	}
+	return user.Username + "#" + user.Discriminator
+}
+
+// MatchUser tells if `identity` is the name of `user`. Migrated users
+// are matched by `username`, `username#0` (legacy format of them) or
+// their unique username.
+func MatchUser(identity string, user *discordgo.User) bool {
+	if user == nil {
+		return false
+	}
+	name, discriminator, _ := strings.Cut(NormalizeIdentity(identity), "#")
+	if discriminator == "0" {
+		discriminator = ""
+	}
+	userDiscriminator := user.Discriminator
+	if userDiscriminator == "0" {
+		userDiscriminator = ""
+	}
+	if discriminator != userDiscriminator {
+		return false
+	}
+	if discriminator == "" {
+		return strings.EqualFold(name, user.Username)
+	}
+	return name == user.Username
+}
+
+// ParseMessageLink gives channel ID and message ID in message link,
+// e.g. `https://discord.com/channels/GUILD_ID/CHANNEL_ID/MESSAGE_ID`.
+// `discordapp.com`, `ptb.` and `canary.` hosts, and `@me` as guild are
+// accepted as well.
+func ParseMessageLink(location string) (channelID, messageID string, err error) {
+	u, err := url.Parse(strings.TrimSpace(location))
+	if err != nil {
+		return "", "", xerrors.Errorf("Error parsing proof location: %w", err)
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "ptb."), "canary.")
+	if host != "discord.com" && host != "discordapp.com" {
+		return "", "", xerrors.Errorf("Not a discord message link: %s", location)
+	}
+	pathArr := strings.Split(strings.Trim(path.Clean(u.Path), "/"), "/")
+	if len(pathArr) != 4 || pathArr[0] != "channels" {
+		return "", "", xerrors.Errorf("Error getting right proof location: %s", location)
+	}
+	for _, id := range pathArr[2:] {
+		if !reSnowflake.MatchString(id) {
+			return "", "", xerrors.Errorf("Error getting right proof location: %s", location)
+		}
+	}
+	return pathArr[2], pathArr[3], nil
+}
+
 func (dc *Discord) validateText() (err error) {
 	scanner := bufio.NewScanner(strings.NewReader(dc.Text))
 	for scanner.Scan() {
@@ -1,8 +1,12 @@
 package discord
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
 	"testing"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/google/uuid"
 	"github.com/nextdotid/proof_server/config"
 	"github.com/nextdotid/proof_server/types"
@@ -72,3 +76,98 @@ func TestDiscord_GeneratePostPayload(t *testing.T) {
 		assert.Contains(t, result["default"], "%SIG_BASE64%")
 	})
 }
+
+func stubMessage(t *testing.T, dc *Discord, author *discordgo.User) {
+	_, sk := crypto.GenerateSecp256k1Keypair()
+	dc.Pubkey = &sk.PublicKey
+	sig, err := crypto.SignPersonal([]byte(dc.GenerateSignPayload()), sk)
+	assert.NoError(t, err)
+	content := "Verifying my discord ID\nSig: " + base64.StdEncoding.EncodeToString(sig)
+
+	original := fetchMessage
+	fetchMessage = func(channelID, messageID string) (*discordgo.Message, []byte, int, error) {
+		assert.Equal(t, "960708146706395179", channelID)
+		assert.Equal(t, "961458176719487076", messageID)
+		msg := &discordgo.Message{Author: author, Content: content}
+		raw, _ := json.Marshal(msg)
+		return msg, raw, http.StatusOK, nil
+	}
+	t.Cleanup(func() { fetchMessage = original })
+}
+
+func TestDiscord_Validate_stub(t *testing.T) {
+	t.Run("unique username", func(t *testing.T) {
+		discord := generate()
+		discord.Identity = "@Sannie"
+		stubMessage(t, &discord, &discordgo.User{ID: "123", Username: "sannie", Discriminator: "0"})
+
+		assert.NoError(t, discord.Validate())
+		assert.Equal(t, "sannie", discord.Identity)
+		assert.Equal(t, "123", discord.AltID)
+		assert.Equal(t, http.StatusOK, discord.Evidence.StatusCode)
+		assert.Contains(t, string(discord.Evidence.Raw), `"username":"sannie"`)
+	})
+
+	t.Run("legacy tag", func(t *testing.T) {
+		discord := generate()
+		stubMessage(t, &discord, &discordgo.User{ID: "123", Username: "Sannie", Discriminator: "0250"})
+
+		assert.NoError(t, discord.Validate())
+		assert.Equal(t, "Sannie#0250", discord.Identity)
+	})
+
+	t.Run("renamed user detected by alt ID", func(t *testing.T) {
+		discord := generate()
+		discord.AltID = "123"
+		stubMessage(t, &discord, &discordgo.User{ID: "123", Username: "new_name", Discriminator: "0"})
+
+		assert.NoError(t, discord.Validate())
+		assert.Equal(t, "new_name", discord.Identity)
+		assert.Equal(t, "123", discord.AltID)
+	})
+
+	t.Run("different user", func(t *testing.T) {
+		discord := generate()
+		discord.AltID = "123"
+		stubMessage(t, &discord, &discordgo.User{ID: "456", Username: "someone", Discriminator: "0"})
+
+		err := discord.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "User name mismatch")
+	})
+}
+
+func TestMatchUser(t *testing.T) {
+	migrated := &discordgo.User{Username: "sannie", Discriminator: "0"}
+	legacy := &discordgo.User{Username: "Sannie", Discriminator: "0250"}
+
+	assert.True(t, MatchUser("Sannie", migrated))
+	assert.True(t, MatchUser("sannie#0", migrated))
+	assert.False(t, MatchUser("sannie#0250", migrated))
+	assert.True(t, MatchUser("Sannie#0250", legacy))
+	assert.False(t, MatchUser("sannie#0250", legacy))
+	assert.False(t, MatchUser("Sannie", legacy))
+}
+
+func TestParseMessageLink(t *testing.T) {
+	for _, link := range []string{
+		"https://discord.com/channels/960708146706395176/960708146706395179/961458176719487076",
+		"https://discordapp.com/channels/960708146706395176/960708146706395179/961458176719487076",
+		"https://ptb.discord.com/channels/960708146706395176/960708146706395179/961458176719487076/",
+		"https://canary.discordapp.com/channels/@me/960708146706395179/961458176719487076",
+	} {
+		channelID, messageID, err := ParseMessageLink(link)
+		assert.NoError(t, err, link)
+		assert.Equal(t, "960708146706395179", channelID)
+		assert.Equal(t, "961458176719487076", messageID)
+	}
+
+	for _, link := range []string{
+		"https://evil.com/channels/1/2/3",
+		"https://discord.com/invite/abc",
+		"https://discord.com/channels/1/2/abc",
+	} {
+		_, _, err := ParseMessageLink(link)
+		assert.Error(t, err, link)
+	}
+}
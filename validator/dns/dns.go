@@ -4,12 +4,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/config"
 	"github.com/nextdotid/proof_server/types"
 	"github.com/nextdotid/proof_server/util"
 	"github.com/nextdotid/proof_server/util/crypto"
@@ -19,10 +19,6 @@ import (
 	"golang.org/x/xerrors"
 )
 
-const (
-	DOH = "https://cloudflare-dns.com/dns-query?type=TXT&name=%s"
-)
-
 // https://developers.cloudflare.com/1.1.1.1/encryption/dns-over-https/make-api-requests/dns-json/
 type DOHResponse struct {
 	// The Response Code of the DNS Query. These are defined here: https://www.iana.org/assignments/dns-parameters/dns-parameters.xhtml#dns-parameters-6Open external link.
@@ -75,6 +71,17 @@ type DNS struct {
 
 const (
 	TXT_PAYLOAD_V1 = "ps:true;v:1;sig:%s;ca:%d;uuid:%s;prev:%s"
+	// LABEL is prepended to domain to keep TXT records of apex domain
+	// clean, i.e. `_nextid.example.com`. Apex domain is still
+	// supported as a fallback.
+	LABEL = "_nextid"
+
+	// EXTRA_DNSSEC is the `Extra` key of DNSSEC validation status
+	// (`true` / `false`) of matched TXT record.
+	EXTRA_DNSSEC = "dnssec"
+	// EXTRA_RECORD_NAME is the `Extra` key of domain name where matched
+	// TXT record is found.
+	EXTRA_RECORD_NAME = "dns_record_name"
 )
 
 var (
@@ -130,19 +137,51 @@ func (dns *DNS) Validate() (err error) {
 	dns.Identity = strings.ToLower(dns.Identity)
 	dns.AltID = dns.Identity
 	dns.SignaturePayload = dns.GenerateSignPayload()
-	query_resp, err := query(dns.Identity)
-	if err != nil {
-		return err
+
+	resolver := NewResolver()
+	var payload TXTPayload
+	found := false
+	// Lookup errors (e.g. SERVFAIL of broken DNSSEC on label only)
+	// are reported only if no name has the record.
+	lookupErrs := []string{}
+	for _, name := range []string{LABEL + "." + dns.Identity, dns.Identity} {
+		result, err := resolver.LookupTXT(name)
+		if err != nil {
+			l.Warnf("looking up TXT of %s: %s", name, err.Error())
+			lookupErrs = append(lookupErrs, err.Error())
+			continue
+		}
+		// TXT answer of the name, which owns the record. Not fetched
+		// by HTTP.
+		raw, _ := json.Marshal(result)
+		dns.RecordEvidence(raw, name, "", 0)
+		txt, ok := lo.Find(result.Records, func(txt string) bool {
+			parsed, parse_err := parseTxt(txt)
+			return parse_err == nil && parsed.uuid == dns.Uuid
+		})
+		if !ok {
+			continue
+		}
+		if config.C.Platform.DNS.RequireDNSSEC && !result.AuthenticatedData {
+			return xerrors.Errorf("TXT record of %s is not validated by DNSSEC.", name)
+		}
+
+		payload, _ = parseTxt(txt)
+		dns.Text = txt
+		if dns.Extra == nil {
+			dns.Extra = map[string]string{}
+		}
+		dns.Extra[EXTRA_DNSSEC] = strconv.FormatBool(result.AuthenticatedData)
+		dns.Extra[EXTRA_RECORD_NAME] = name
+		found = true
+		break
 	}
-	txt, found := lo.Find(*query_resp.Answer, func(txt DOHAnswer) bool {
-		result, parse_err := parseTxt(txt.Data)
-		return parse_err == nil && result.uuid == dns.Uuid
-	})
 	if !found {
+		if len(lookupErrs) > 0 {
+			return xerrors.Errorf("matched TXT record not found: %s", strings.Join(lookupErrs, "; "))
+		}
 		return xerrors.New("matched TXT record not found.")
 	}
-	payload, _ := parseTxt(txt.Data)
-	dns.Text = txt.Data
 	dns.Signature, err = base64.StdEncoding.DecodeString(payload.Signature)
 	if err != nil {
 		return xerrors.New("sig in TXT record cannot be recognized.")
@@ -155,34 +194,6 @@ func (dns *DNS) GetAltID() string {
 	return dns.AltID
 }
 
-func query(domain string) (doh_response *DOHResponse, err error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf(DOH, domain), nil)
-	req.Header.Set("Accept", "application/dns-json")
-	resp, err := (&http.Client{}).Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		return nil, xerrors.Errorf("status code %d", resp.StatusCode)
-	}
-	defer resp.Body.Close()
-	bytes_body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	doh_response = new(DOHResponse)
-	err = json.Unmarshal(bytes_body, doh_response)
-	if err != nil {
-		return nil, err
-	}
-	if doh_response.Answer == nil {
-		return nil, xerrors.Errorf("No TXT result found for domain %s .", domain)
-	}
-
-	return doh_response, nil
-}
-
 func parseTxt(txtField string) (result TXTPayload, err error) {
 	kv := make(map[string]string)
 
@@ -35,17 +35,18 @@ func build_invalid() DNS {
 }
 
 func Test_query(t *testing.T) {
+	resolver := &DOHJSONResolver{Endpoints: []string{DEFAULT_DOH_ENDPOINT}}
 	t.Run("not found", func(t *testing.T) {
-		_, err := query("nonexist.example.com")
-		t.Log(err.Error())
-		require.Error(t, err)
+		result, err := resolver.LookupTXT("nonexist.example.com")
+		require.NoError(t, err)
+		require.Empty(t, result.Records)
 	})
 
 	t.Run("found", func(t *testing.T) {
-		body, err := query("example.com")
+		result, err := resolver.LookupTXT("example.com")
 		require.NoError(t, err)
-		require.NotNil(t, body)
-		require.NotNil(t, body.Answer)
+		require.NotNil(t, result)
+		require.NotEmpty(t, result.Records)
 	})
 }
 
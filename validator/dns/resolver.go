@@ -0,0 +1,235 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"github.com/nextdotid/proof_server/config"
+	"golang.org/x/xerrors"
+)
+
+const (
+	DEFAULT_DOH_ENDPOINT = "https://cloudflare-dns.com/dns-query"
+	DEFAULT_DNS_SERVER   = "1.1.1.1:53"
+	QUERY_TIMEOUT        = 5 * time.Second
+)
+
+// Resolver looks up TXT records of a domain name.
+type Resolver interface {
+	LookupTXT(name string) (*TXTResult, error)
+}
+
+type TXTResult struct {
+	// Each record with its character-strings joined. Empty if name
+	// (or TXT record of it) not exists.
+	Records []string
+	// `AD` flag: every record in the answer was validated with DNSSEC
+	// by resolver.
+	AuthenticatedData bool
+}
+
+// NewResolver gives the resolver configured in `platform.dns`.
+// Replaceable for testing.
+var NewResolver = func() Resolver {
+	dnsConfig := config.C.Platform.DNS
+	switch dnsConfig.Resolver {
+	case "doh":
+		return &DOHWireResolver{Endpoints: withDefault(dnsConfig.Servers, DEFAULT_DOH_ENDPOINT)}
+	case "udp", "tcp":
+		return &ClassicResolver{Net: dnsConfig.Resolver, Servers: withDefault(dnsConfig.Servers, DEFAULT_DNS_SERVER)}
+	default:
+		return &DOHJSONResolver{Endpoints: withDefault(dnsConfig.Servers, DEFAULT_DOH_ENDPOINT)}
+	}
+}
+
+// DOHJSONResolver uses JSON API of DNS-over-HTTPS providers.
+// https://developers.cloudflare.com/1.1.1.1/encryption/dns-over-https/make-api-requests/dns-json/
+type DOHJSONResolver struct {
+	Endpoints []string
+}
+
+func (r *DOHJSONResolver) LookupTXT(name string) (*TXTResult, error) {
+	return tryEach(r.Endpoints, func(endpoint string) (*TXTResult, error) {
+		resp, err := queryDOHJSON(endpoint, name)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Status != mdns.RcodeSuccess && resp.Status != mdns.RcodeNameError {
+			return nil, xerrors.Errorf("DNS query of %s failed: %s", name, mdns.RcodeToString[int(resp.Status)])
+		}
+		result := &TXTResult{AuthenticatedData: resp.AD}
+		if resp.Answer != nil {
+			for _, answer := range *resp.Answer {
+				if answer.Type == int(mdns.TypeTXT) {
+					result.Records = append(result.Records, unquoteTXT(answer.Data))
+				}
+			}
+		}
+		return result, nil
+	})
+}
+
+// DOHWireResolver uses DNS wire format over HTTPS (RFC 8484).
+type DOHWireResolver struct {
+	Endpoints []string
+}
+
+func (r *DOHWireResolver) LookupTXT(name string) (*TXTResult, error) {
+	return tryEach(r.Endpoints, func(endpoint string) (*TXTResult, error) {
+		msg := newTXTQuery(name)
+		// https://www.rfc-editor.org/rfc/rfc8484#section-4.1
+		msg.Id = 0
+		packed, err := msg.Pack()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(packed))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/dns-message")
+		req.Header.Set("Accept", "application/dns-message")
+		resp, err := (&http.Client{Timeout: QUERY_TIMEOUT}).Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, xerrors.Errorf("status code %d", resp.StatusCode)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, mdns.MaxMsgSize))
+		if err != nil {
+			return nil, err
+		}
+		answer := new(mdns.Msg)
+		if err := answer.Unpack(body); err != nil {
+			return nil, xerrors.Errorf("error when parsing DNS message: %w", err)
+		}
+		return parseTXTAnswer(name, answer)
+	})
+}
+
+// ClassicResolver queries recursive resolvers over UDP or TCP.
+// Truncated UDP answers are retried over TCP.
+type ClassicResolver struct {
+	// `udp` or `tcp`
+	Net     string
+	Servers []string
+}
+
+func (r *ClassicResolver) LookupTXT(name string) (*TXTResult, error) {
+	return tryEach(r.Servers, func(server string) (*TXTResult, error) {
+		client := &mdns.Client{Net: r.Net, Timeout: QUERY_TIMEOUT}
+		answer, _, err := client.Exchange(newTXTQuery(name), server)
+		if err == nil && answer.Truncated && r.Net != "tcp" {
+			client.Net = "tcp"
+			answer, _, err = client.Exchange(newTXTQuery(name), server)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseTXTAnswer(name, answer)
+	})
+}
+
+// queryDOHJSON makes a TXT query to a DoH JSON API endpoint.
+func queryDOHJSON(endpoint, name string) (doh_response *DOHResponse, err error) {
+	query := url.Values{"type": {"TXT"}, "name": {name}}
+	req, err := http.NewRequest("GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+	resp, err := (&http.Client{Timeout: QUERY_TIMEOUT}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, xerrors.Errorf("status code %d", resp.StatusCode)
+	}
+	bytes_body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	doh_response = new(DOHResponse)
+	if err = json.Unmarshal(bytes_body, doh_response); err != nil {
+		return nil, err
+	}
+	return doh_response, nil
+}
+
+func newTXTQuery(name string) *mdns.Msg {
+	msg := new(mdns.Msg)
+	msg.SetQuestion(mdns.Fqdn(name), mdns.TypeTXT)
+	// Ask for DNSSEC validation result (`DO` bit and `AD` flag).
+	msg.SetEdns0(4096, true)
+	msg.AuthenticatedData = true
+	return msg
+}
+
+func parseTXTAnswer(name string, answer *mdns.Msg) (*TXTResult, error) {
+	if answer.Rcode != mdns.RcodeSuccess && answer.Rcode != mdns.RcodeNameError {
+		return nil, xerrors.Errorf("DNS query of %s failed: %s", name, mdns.RcodeToString[answer.Rcode])
+	}
+	result := &TXTResult{AuthenticatedData: answer.AuthenticatedData}
+	for _, rr := range answer.Answer {
+		if txt, ok := rr.(*mdns.TXT); ok {
+			result.Records = append(result.Records, strings.Join(txt.Txt, ""))
+		}
+	}
+	return result, nil
+}
+
+// unquoteTXT joins character-strings in presentation format, e.g.
+// `"abc" "def"` becomes `abcdef`. Unquoted data is returned as-is.
+func unquoteTXT(data string) string {
+	if !strings.HasPrefix(data, "\"") {
+		return data
+	}
+	result := strings.Builder{}
+	quoted, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			result.WriteRune(c)
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			result.WriteRune(c)
+		}
+	}
+	return result.String()
+}
+
+// tryEach queries servers in order, until one of them answers.
+func tryEach(servers []string, query func(server string) (*TXTResult, error)) (*TXTResult, error) {
+	errs := make([]string, 0, len(servers))
+	for _, server := range servers {
+		result, err := query(server)
+		if err == nil {
+			return result, nil
+		}
+		l.Warnf("DNS query via %s failed: %s", server, err.Error())
+		errs = append(errs, fmt.Sprintf("%s: %s", server, err.Error()))
+	}
+	return nil, xerrors.Errorf("all DNS resolvers failed: %s", strings.Join(errs, "; "))
+}
+
+func withDefault(servers []string, defaultServer string) []string {
+	if len(servers) == 0 {
+		return []string{defaultServer}
+	}
+	return servers
+}
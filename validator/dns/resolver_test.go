@@ -0,0 +1,227 @@
+package dns
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mdns "github.com/miekg/dns"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// stubZone answers TXT queries from memory. Long records are split
+// into 255-byte character-strings, like real zones do.
+type stubZone struct {
+	records map[string][]string
+	secure  bool
+	// servfail are names answered with SERVFAIL.
+	servfail map[string]bool
+}
+
+func (zone *stubZone) answer(query *mdns.Msg) *mdns.Msg {
+	resp := new(mdns.Msg)
+	resp.SetReply(query)
+	name := strings.ToLower(query.Question[0].Name)
+	if zone.servfail[strings.TrimSuffix(name, ".")] {
+		resp.Rcode = mdns.RcodeServerFailure
+		return resp
+	}
+	records, ok := zone.records[strings.TrimSuffix(name, ".")]
+	if !ok {
+		resp.Rcode = mdns.RcodeNameError
+		return resp
+	}
+	for _, record := range records {
+		parts := []string{}
+		for len(record) > 255 {
+			parts, record = append(parts, record[:255]), record[255:]
+		}
+		resp.Answer = append(resp.Answer, &mdns.TXT{
+			Hdr: mdns.RR_Header{Name: name, Rrtype: mdns.TypeTXT, Class: mdns.ClassINET, Ttl: 300},
+			Txt: append(parts, record),
+		})
+	}
+	resp.AuthenticatedData = zone.secure
+	return resp
+}
+
+func (zone *stubZone) ServeDNS(w mdns.ResponseWriter, query *mdns.Msg) {
+	w.WriteMsg(zone.answer(query))
+}
+
+// serve starts UDP and TCP stub DNS servers sharing one address.
+func (zone *stubZone) serve(t *testing.T) (addr string, shutdown func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", pc.LocalAddr().String())
+	require.NoError(t, err)
+
+	udpServer := &mdns.Server{PacketConn: pc, Handler: zone}
+	tcpServer := &mdns.Server{Listener: listener, Handler: zone}
+	go udpServer.ActivateAndServe()
+	go tcpServer.ActivateAndServe()
+	return pc.LocalAddr().String(), func() {
+		udpServer.Shutdown()
+		tcpServer.Shutdown()
+	}
+}
+
+func useResolver(t *testing.T, dnsConfig config.DNSPlatformConfig) func() {
+	config.C.Platform.DNS = dnsConfig
+	return func() { config.C.Platform.DNS = config.DNSPlatformConfig{} }
+}
+
+// signedZone gives a zone containing a signed TXT record of `dns` at `name`.
+func signedZone(t *testing.T, dns *DNS, name string) *stubZone {
+	pk, sk := crypto.GenerateSecp256k1Keypair()
+	dns.Pubkey = pk
+	sig, err := crypto.SignPersonal([]byte(dns.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	record := strings.ReplaceAll(dns.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+	return &stubZone{records: map[string][]string{
+		name:                    {"v=spf1 -all", record},
+		"unrelated.nextnext.id": {"hello"},
+	}}
+}
+
+func Test_ClassicResolver(t *testing.T) {
+	zone := &stubZone{records: map[string][]string{"example.com": {"hello", strings.Repeat("a", 300)}}, secure: true}
+	addr, shutdown := zone.serve(t)
+	defer shutdown()
+
+	for _, network := range []string{"udp", "tcp"} {
+		t.Run(network, func(t *testing.T) {
+			resolver := &ClassicResolver{Net: network, Servers: []string{"127.0.0.1:1", addr}}
+			result, err := resolver.LookupTXT("example.com")
+			require.NoError(t, err)
+			require.Equal(t, []string{"hello", strings.Repeat("a", 300)}, result.Records)
+			require.True(t, result.AuthenticatedData)
+
+			result, err = resolver.LookupTXT("nonexist.example.com")
+			require.NoError(t, err)
+			require.Empty(t, result.Records)
+		})
+	}
+}
+
+func Test_DOHWireResolver(t *testing.T) {
+	zone := &stubZone{records: map[string][]string{"example.com": {"hello"}}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		query := new(mdns.Msg)
+		if r.Header.Get("Content-Type") != "application/dns-message" || query.Unpack(body) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		packed, _ := zone.answer(query).Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+	defer ts.Close()
+
+	result, err := (&DOHWireResolver{Endpoints: []string{ts.URL}}).LookupTXT("example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello"}, result.Records)
+	require.False(t, result.AuthenticatedData)
+}
+
+func Test_DOHJSONResolver(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer := []DOHAnswer{
+			{Name: "example.com", Type: 5, Data: "alias.example.com."},
+			{Name: "alias.example.com", Type: 16, Data: `"hel" "lo\"!"`},
+		}
+		json.NewEncoder(w).Encode(DOHResponse{Status: 0, AD: true, Answer: &answer})
+	}))
+	defer ts.Close()
+
+	result, err := (&DOHJSONResolver{Endpoints: []string{ts.URL}}).LookupTXT("example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{`hello"!`}, result.Records)
+	require.True(t, result.AuthenticatedData)
+}
+
+func Test_Validate_stub(t *testing.T) {
+	t.Run("success with _nextid label", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "_nextid.testcase.nextnext.id")
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "udp", Servers: []string{addr}})()
+
+		require.NoError(t, dns.Validate())
+		require.Equal(t, "_nextid.testcase.nextnext.id", dns.Extra[EXTRA_RECORD_NAME])
+		require.Equal(t, "false", dns.Extra[EXTRA_DNSSEC])
+	})
+
+	t.Run("success with apex domain", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "testcase.nextnext.id")
+		zone.secure = true
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "tcp", Servers: []string{addr}, RequireDNSSEC: true})()
+
+		require.NoError(t, dns.Validate())
+		require.Equal(t, "testcase.nextnext.id", dns.Extra[EXTRA_RECORD_NAME])
+		require.Equal(t, "true", dns.Extra[EXTRA_DNSSEC])
+	})
+
+	t.Run("DNSSEC required", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "testcase.nextnext.id")
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "udp", Servers: []string{addr}, RequireDNSSEC: true})()
+
+		err := dns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not validated by DNSSEC")
+	})
+
+	t.Run("apex domain when label fails", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "testcase.nextnext.id")
+		zone.servfail = map[string]bool{"_nextid.testcase.nextnext.id": true}
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "udp", Servers: []string{addr}})()
+
+		require.NoError(t, dns.Validate())
+		require.Equal(t, "testcase.nextnext.id", dns.Extra[EXTRA_RECORD_NAME])
+		require.Equal(t, "testcase.nextnext.id", dns.Evidence.Author)
+		require.Contains(t, string(dns.Evidence.Raw), dns.Text[:10])
+	})
+
+	t.Run("lookup failed", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "testcase.nextnext.id")
+		zone.servfail = map[string]bool{"_nextid.testcase.nextnext.id": true, "testcase.nextnext.id": true}
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "udp", Servers: []string{addr}})()
+
+		err := dns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "SERVFAIL")
+	})
+
+	t.Run("record not found", func(t *testing.T) {
+		dns := build()
+		zone := signedZone(t, &dns, "other.nextnext.id")
+		addr, shutdown := zone.serve(t)
+		defer shutdown()
+		defer useResolver(t, config.DNSPlatformConfig{Resolver: "udp", Servers: []string{addr}})()
+
+		err := dns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "matched TXT record not found")
+	})
+}
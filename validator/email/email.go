@@ -0,0 +1,266 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-msgauth/dkim"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// Email binds an email address to a persona. User sends an email
+// containing `Sig:` line, then uploads the raw RFC 5322 message. No
+// SMTP service is needed: authenticity of the message is ensured by
+// DKIM signature of sender domain.
+type Email struct {
+	*validator.Base
+}
+
+const (
+	MATCH_TEMPLATE = "^Sig: (.+)$"
+
+	// EXTRA_RAW_MESSAGE is the `Extra` key of uploaded raw message.
+	// It will be removed from `Extra` after validation.
+	EXTRA_RAW_MESSAGE = "email_raw_message"
+	// EXTRA_MESSAGE_HASH is the `Extra` key of SHA256 hexstring of raw message.
+	EXTRA_MESSAGE_HASH = "message_hash"
+)
+
+var (
+	l           = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "email"})
+	re          = regexp.MustCompile(MATCH_TEMPLATE)
+	POST_STRUCT = map[string]string{
+		"default": "🎭 Verifying my email address %s for @NextDotID.\nSig: %%SIG_BASE64%%\n\nPowered by Next.ID - Connect All Digital Identities.\n",
+		"en_US":   "🎭 Verifying my email address %s for @NextDotID.\nSig: %%SIG_BASE64%%\n\nPowered by Next.ID - Connect All Digital Identities.\n",
+		"zh_CN":   "🎭 正在通过 @NextDotID 验证我的邮箱地址 %s 。\nSig: %%SIG_BASE64%%\n\n由 Next.ID 支持 - 连接全域数字身份。\n",
+	}
+	// LookupTXT resolves DKIM public key records. Replaceable for testing.
+	LookupTXT = net.LookupTXT
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.Email] = func(base *validator.Base) validator.IValidator {
+		email := Email{base}
+		return &email
+	}
+}
+
+func (email *Email) GeneratePostPayload() (post map[string]string) {
+	email.Identity = strings.ToLower(strings.TrimSpace(email.Identity))
+	post = make(map[string]string)
+	for langCode, template := range POST_STRUCT {
+		post[langCode] = fmt.Sprintf(template, email.Identity)
+	}
+	return post
+}
+
+func (email *Email) GenerateSignPayload() (payload string) {
+	email.Identity = strings.ToLower(strings.TrimSpace(email.Identity))
+	payloadStruct := validator.H{
+		"action":     string(email.Action),
+		"identity":   email.Identity,
+		"platform":   string(types.Platforms.Email),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(email.CreatedAt),
+		"uuid":       email.Uuid.String(),
+	}
+	if email.Previous != "" {
+		payloadStruct["prev"] = email.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshaling struct: %s", err.Error())
+		return ""
+	}
+
+	return string(payloadBytes)
+}
+
+func (email *Email) Validate() (err error) {
+	email.Identity = strings.ToLower(strings.TrimSpace(email.Identity))
+	email.AltID = email.Identity
+	email.SignaturePayload = email.GenerateSignPayload()
+
+	rawMessage := email.Extra[EXTRA_RAW_MESSAGE]
+	// Deletion, or revalidation of an existed proof (raw message is
+	// not kept after upload). Only persona signature can be checked.
+	if email.Action == types.Actions.Delete || rawMessage == "" {
+		if email.Action != types.Actions.Delete && email.Extra[EXTRA_MESSAGE_HASH] == "" {
+			return xerrors.Errorf("%s not found", EXTRA_RAW_MESSAGE)
+		}
+		return mycrypto.ValidatePersonalSignature(email.SignaturePayload, email.Signature, email.Pubkey)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(rawMessage))
+	if err != nil {
+		return xerrors.Errorf("error when parsing message: %w", err)
+	}
+	// DKIM signature covers the last one of `From` headers, while
+	// the first one is shown, so only one is accepted.
+	if len(msg.Header["From"]) != 1 {
+		return xerrors.Errorf("expect exactly one From header, got %d", len(msg.Header["From"]))
+	}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return xerrors.Errorf("error when parsing From header: %w", err)
+	}
+	if strings.ToLower(from.Address) != email.Identity {
+		return xerrors.Errorf("sender mismatch: expect %s, got %s", email.Identity, from.Address)
+	}
+	if err := verifyDKIM(rawMessage, domainOf(email.Identity)); err != nil {
+		return err
+	}
+
+	email.Text, err = extractText(msg.Header, msg.Body)
+	if err != nil {
+		return xerrors.Errorf("error when reading message body: %w", err)
+	}
+	if err := email.validateText(); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(rawMessage))
+	delete(email.Extra, EXTRA_RAW_MESSAGE)
+	email.Extra[EXTRA_MESSAGE_HASH] = hex.EncodeToString(hash[:])
+	return nil
+}
+
+func (email *Email) GetAltID() string {
+	return email.AltID
+}
+
+func (email *Email) validateText() (err error) {
+	scanner := bufio.NewScanner(strings.NewReader(email.Text))
+	for scanner.Scan() {
+		matched := re.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if len(matched) < 2 {
+			continue // Search for next line
+		}
+
+		sigBase64 := matched[1]
+		sigBytes, err := util.DecodeString(sigBase64)
+		if err != nil {
+			return xerrors.Errorf("decoding signature %s: %s", sigBase64, err.Error())
+		}
+		email.Signature = sigBytes
+		return mycrypto.ValidatePersonalSignature(email.SignaturePayload, sigBytes, email.Pubkey)
+	}
+	return xerrors.Errorf("Signature not found in email body.")
+}
+
+// verifyDKIM ensures at least one valid DKIM signature is made by
+// sender domain (or its parent domain, i.e. relaxed alignment), with
+// `From` header signed.
+func verifyDKIM(rawMessage, senderDomain string) error {
+	verifications, err := dkim.VerifyWithOptions(strings.NewReader(rawMessage), &dkim.VerifyOptions{
+		LookupTXT:        LookupTXT,
+		MaxVerifications: 5,
+	})
+	if err != nil && err != dkim.ErrTooManySignatures {
+		return xerrors.Errorf("error when verifying DKIM signature: %w", err)
+	}
+	if len(verifications) == 0 {
+		return xerrors.New("DKIM signature not found")
+	}
+
+	reasons := make([]string, 0, len(verifications))
+	for _, v := range verifications {
+		if v.Err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", v.Domain, v.Err.Error()))
+			continue
+		}
+		if !lo.ContainsBy(v.HeaderKeys, func(key string) bool { return strings.EqualFold(key, "From") }) {
+			reasons = append(reasons, fmt.Sprintf("%s: From header not signed", v.Domain))
+			continue
+		}
+		domain := strings.ToLower(v.Domain)
+		if senderDomain == domain || strings.HasSuffix(senderDomain, "."+domain) {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: not sender domain", v.Domain))
+	}
+	return xerrors.Errorf("no valid DKIM signature of %s found (%s)", senderDomain, strings.Join(reasons, "; "))
+}
+
+// extractText gives the first `text/plain` part of a message body, decoded.
+func extractText(header mail.Header, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return "", xerrors.New("text/plain part not found")
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := extractText(mail.Header(part.Header), part)
+			if err == nil {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", xerrors.Errorf("unsupported content type %s", mediaType)
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{body})
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))), nil
+}
+
+func domainOf(address string) string {
+	return address[strings.LastIndex(address, "@")+1:]
+}
+
+// newlineStripper removes line breaks in base64-encoded MIME body.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	j := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
@@ -0,0 +1,157 @@
+package email
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-msgauth/dkim"
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func generate(pubkey *ecdsa.PublicKey) Email {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return Email{
+		Base: &validator.Base{
+			Platform:  types.Platforms.Email,
+			Previous:  "",
+			Action:    types.Actions.Create,
+			Pubkey:    pubkey,
+			Identity:  "Alice@Example.COM",
+			CreatedAt: created_at,
+			Uuid:      uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+			Extra:     map[string]string{},
+		},
+	}
+}
+
+// stubDNS makes LookupTXT return a DKIM key record for `selector._domainkey.domain`.
+func stubDNS(t *testing.T, domain string) ed25519.PrivateKey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	LookupTXT = func(name string) ([]string, error) {
+		if name != "nextid._domainkey."+domain {
+			return nil, xerrors.Errorf("NXDOMAIN: %s", name)
+		}
+		return []string{"v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub)}, nil
+	}
+	return priv
+}
+
+// buildMessage composes a message from `from` with persona-signed
+// body, and DKIM-signs it as `signDomain`.
+func buildMessage(t *testing.T, email *Email, sk *ecdsa.PrivateKey, from, signDomain string, dkimKey ed25519.PrivateKey) string {
+	sig, err := mycrypto.SignPersonal([]byte(email.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	body := strings.ReplaceAll(email.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+	raw := fmt.Sprintf("From: Alice <%s>\r\nTo: proof@next.id\r\nSubject: NextID proof\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		from, strings.ReplaceAll(body, "\n", "\r\n"))
+
+	signed := new(bytes.Buffer)
+	err = dkim.Sign(signed, strings.NewReader(raw), &dkim.SignOptions{
+		Domain:   signDomain,
+		Selector: "nextid",
+		Signer:   dkimKey,
+	})
+	require.NoError(t, err)
+	return signed.String()
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "example.com")
+		email.Extra[EXTRA_RAW_MESSAGE] = buildMessage(t, &email, sk, "alice@example.com", "example.com", dkimKey)
+
+		require.NoError(t, email.Validate())
+		require.Equal(t, "alice@example.com", email.Identity)
+		require.Equal(t, email.Identity, email.AltID)
+		require.Len(t, email.Extra[EXTRA_MESSAGE_HASH], 64)
+		require.NotContains(t, email.Extra, EXTRA_RAW_MESSAGE)
+	})
+
+	t.Run("sender mismatch", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "example.com")
+		email.Extra[EXTRA_RAW_MESSAGE] = buildMessage(t, &email, sk, "bob@example.com", "example.com", dkimKey)
+
+		err := email.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sender mismatch")
+	})
+
+	t.Run("forged From prepended", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "example.com")
+		// DKIM-signed for mallory, whose From is the last one.
+		raw := buildMessage(t, &email, sk, "mallory@example.com", "example.com", dkimKey)
+		email.Extra[EXTRA_RAW_MESSAGE] = "From: Alice <alice@example.com>\r\n" + raw
+
+		err := email.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exactly one From header")
+	})
+
+	t.Run("signed by another domain", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "evil.com")
+		email.Extra[EXTRA_RAW_MESSAGE] = buildMessage(t, &email, sk, "alice@example.com", "evil.com", dkimKey)
+
+		err := email.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no valid DKIM signature")
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "example.com")
+		raw := buildMessage(t, &email, sk, "alice@example.com", "example.com", dkimKey)
+		email.Extra[EXTRA_RAW_MESSAGE] = strings.Replace(raw, "Verifying", "Verified", 1)
+
+		err := email.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no valid DKIM signature")
+	})
+
+	t.Run("signed by another persona", func(t *testing.T) {
+		pk, _ := mycrypto.GenerateSecp256k1Keypair()
+		_, otherSk := mycrypto.GenerateSecp256k1Keypair()
+		email := generate(pk)
+		dkimKey := stubDNS(t, "example.com")
+		email.Extra[EXTRA_RAW_MESSAGE] = buildMessage(t, &email, otherSk, "alice@example.com", "example.com", dkimKey)
+
+		require.Error(t, email.Validate())
+	})
+}
+
+func Test_extractText(t *testing.T) {
+	t.Run("multipart with quoted-printable", func(t *testing.T) {
+		raw := "Content-Type: multipart/alternative; boundary=b1\r\n\r\n" +
+			"--b1\r\nContent-Type: text/html\r\n\r\n<p>nope</p>\r\n" +
+			"--b1\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nSig: abc=3D\r\n" +
+			"--b1--\r\n"
+		email := Email{Base: &validator.Base{}}
+		msg, err := mail.ReadMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		email.Text, err = extractText(msg.Header, msg.Body)
+		require.NoError(t, err)
+		require.Contains(t, email.Text, "Sig: abc=")
+	})
+}
@@ -1,6 +1,7 @@
 package ens
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -17,13 +18,12 @@ import (
 	"github.com/nextdotid/proof_server/validator"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
-	ensv3 "github.com/wealdtech/go-ens/v3"
 	"golang.org/x/xerrors"
 )
 
 const ensKey = "id.next.proof"
 
-var client *ethclient.Client
+var client bind.ContractCaller
 
 type TXTPayload struct {
 	Version   uint
@@ -91,24 +91,17 @@ func (ens *ENS) GenerateSignPayload() (payload string) {
 }
 
 func (ens *ENS) Validate() (err error) {
-	initClient()
+	if err := initClient(); err != nil {
+		return xerrors.Errorf("error when connecting to ethereum RPC: %w", err)
+	}
 	// domain name is case-insensitive
 	ens.Identity = strings.ToLower(ens.Identity)
 	ens.AltID = ens.Identity
 	ens.SignaturePayload = ens.GenerateSignPayload()
-	resolver, err := ensv3.NewDNSResolver(client, ens.Identity)
-	if err != nil {
-		return xerrors.Errorf("While finding the ens resolver for the given name: %v", err)
-	}
-	nh, err := ensv3.NameHash(ens.Identity)
-	if err != nil {
-		return xerrors.Errorf("While hashing the ens name: %v", err)
-	}
-	txt, err := resolver.Contract.Text(&bind.CallOpts{}, nh, ensKey)
+	txtData, err := ResolveText(context.Background(), client, ens.Identity, ensKey)
 	if err != nil {
 		return xerrors.Errorf("matched TXT record couldn't be retrieved: %v", err)
 	}
-	txtData := string(txt)
 	payload, _ := parseTxt(txtData)
 	ens.Text = txtData
 	ens.Signature, err = base64.StdEncoding.DecodeString(payload.Signature)
@@ -116,7 +109,28 @@ func (ens *ENS) Validate() (err error) {
 		return xerrors.New("sig in TXT record cannot be recognized.")
 	}
 
-	return crypto.ValidatePersonalSignature(ens.SignaturePayload, ens.Signature, ens.Pubkey)
+	if err := crypto.ValidatePersonalSignature(ens.SignaturePayload, ens.Signature, ens.Pubkey); err != nil {
+		return err
+	}
+	if config.C.Platform.ENS.CheckOwner {
+		ens.recordOwner()
+	}
+	return nil
+}
+
+// recordOwner saves owner of the name into `Extra`. Failure of it
+// does not fail the validation: proof is still valid without it.
+func (ens *ENS) recordOwner() {
+	if ens.Extra == nil {
+		ens.Extra = map[string]string{}
+	}
+	owner, err := ResolveOwner(context.Background(), client, ens.Identity)
+	if err != nil {
+		l.Warnf("error when resolving owner of %s: %s", ens.Identity, err.Error())
+		delete(ens.Extra, validator.EXTRA_OWNER)
+		return
+	}
+	ens.Extra[validator.EXTRA_OWNER] = strings.ToLower(owner.Hex())
 }
 
 func (ens *ENS) GetAltID() string {
@@ -176,7 +190,10 @@ func initClient() error {
 	if client != nil {
 		return nil
 	}
-	var err error
-	client, err = ethclient.Dial(config.C.Platform.Ethereum.RPCServer)
-	return err
+	ethClient, err := ethclient.Dial(config.C.Platform.Ethereum.RPCServer)
+	if err != nil {
+		return err
+	}
+	client = ethClient
+	return nil
 }
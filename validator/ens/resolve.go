@@ -0,0 +1,357 @@
+package ens
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ensv3 "github.com/wealdtech/go-ens/v3"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// Only the functions used in resolution.
+	ENS_ABI = `[
+		{"type":"function","name":"resolver","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+		{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceID","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"text","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"},{"name":"key","type":"string"}],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"resolve","stateMutability":"view","inputs":[{"name":"name","type":"bytes"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"bytes"}]},
+		{"type":"function","name":"owner","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+		{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"address"}]}
+	]`
+
+	// MAX_CCIP_LOOKUPS limits nested `OffchainLookup`s of one call.
+	MAX_CCIP_LOOKUPS = 4
+)
+
+var (
+	// REGISTRY is the ENS registry on Ethereum mainnet. Replaceable for testing.
+	REGISTRY = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	// NAME_WRAPPER owns wrapped names in registry, holding the actual
+	// owner as an ERC-1155 token. Replaceable for testing.
+	NAME_WRAPPER = common.HexToAddress("0xD4416b13d2b3a9aBae7AcD5D6C2BbDBE25686401")
+
+	// https://docs.ens.domains/ensip/10
+	interfaceIDExtendedResolver = [4]byte{0x90, 0x61, 0xb9, 0x23}
+	// `OffchainLookup(address,string[],bytes,bytes4,bytes)`
+	// https://eips.ethereum.org/EIPS/eip-3668
+	selectorOffchainLookup = []byte{0x55, 0x6f, 0x18, 0x30}
+
+	ensABI             = mustParseABI(ENS_ABI)
+	offchainLookupArgs = mustArguments("address", "string[]", "bytes", "bytes4", "bytes")
+	callbackArgs       = mustArguments("bytes", "bytes")
+
+	ccipClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+type offchainLookup struct {
+	Sender           common.Address
+	URLs             []string
+	CallData         []byte
+	CallbackFunction [4]byte
+	ExtraData        []byte
+}
+
+// ResolveText reads text record `key` of `name` the way ENS Universal
+// Resolver does: resolver of the closest ancestor is used if `name`
+// has none (ENSIP-10 wildcard resolution), and offchain lookups
+// requested by resolver are followed (EIP-3668 CCIP-read).
+func ResolveText(ctx context.Context, caller bind.ContractCaller, name, key string) (string, error) {
+	node, err := ensv3.NameHash(name)
+	if err != nil {
+		return "", xerrors.Errorf("error when hashing the ens name: %w", err)
+	}
+	resolver, exact, err := findResolver(ctx, caller, name)
+	if err != nil {
+		return "", err
+	}
+	textCall, err := ensABI.Pack("text", node, key)
+	if err != nil {
+		return "", err
+	}
+
+	var output []byte
+	if supportsExtendedResolver(ctx, caller, resolver) {
+		dnsName, err := dnsEncode(name)
+		if err != nil {
+			return "", err
+		}
+		resolveCall, err := ensABI.Pack("resolve", dnsName, textCall)
+		if err != nil {
+			return "", err
+		}
+		result, err := ccipCall(ctx, caller, resolver, resolveCall)
+		if err != nil {
+			return "", err
+		}
+		unpacked, err := ensABI.Unpack("resolve", result)
+		if err != nil {
+			return "", xerrors.Errorf("error when decoding resolve() result: %w", err)
+		}
+		output = unpacked[0].([]byte)
+	} else {
+		if !exact {
+			return "", xerrors.Errorf("resolver %s of parent name does not support wildcard resolution", resolver.Hex())
+		}
+		if output, err = ccipCall(ctx, caller, resolver, textCall); err != nil {
+			return "", err
+		}
+	}
+
+	unpacked, err := ensABI.Unpack("text", output)
+	if err != nil {
+		return "", xerrors.Errorf("error when decoding text() result: %w", err)
+	}
+	return unpacked[0].(string), nil
+}
+
+// ResolveOwner gives the address managing `name` in ENS registry. For
+// names wrapped by NameWrapper, owner of the wrapped token is given
+// instead.
+func ResolveOwner(ctx context.Context, caller bind.ContractCaller, name string) (common.Address, error) {
+	node, err := ensv3.NameHash(name)
+	if err != nil {
+		return common.Address{}, xerrors.Errorf("error when hashing the ens name: %w", err)
+	}
+	owner, err := callAddress(ctx, caller, REGISTRY, "owner", node)
+	if err != nil {
+		return common.Address{}, xerrors.Errorf("error when querying ens registry: %w", err)
+	}
+	if owner == NAME_WRAPPER {
+		owner, err = callAddress(ctx, caller, NAME_WRAPPER, "ownerOf", new(big.Int).SetBytes(node[:]))
+		if err != nil {
+			return common.Address{}, xerrors.Errorf("error when querying name wrapper: %w", err)
+		}
+	}
+	if owner == (common.Address{}) {
+		return common.Address{}, xerrors.Errorf("owner of %s not found", name)
+	}
+	return owner, nil
+}
+
+// findResolver gives resolver of `name`, or of its closest ancestor
+// (`exact` is false in this case).
+func findResolver(ctx context.Context, caller bind.ContractCaller, name string) (resolver common.Address, exact bool, err error) {
+	labels := strings.Split(name, ".")
+	for i := range labels {
+		node, err := ensv3.NameHash(strings.Join(labels[i:], "."))
+		if err != nil {
+			return common.Address{}, false, xerrors.Errorf("error when hashing the ens name: %w", err)
+		}
+		data, err := ensABI.Pack("resolver", node)
+		if err != nil {
+			return common.Address{}, false, err
+		}
+		result, err := call(ctx, caller, REGISTRY, data)
+		if err != nil {
+			return common.Address{}, false, xerrors.Errorf("error when querying ens registry: %w", err)
+		}
+		unpacked, err := ensABI.Unpack("resolver", result)
+		if err != nil {
+			return common.Address{}, false, xerrors.Errorf("error when decoding ens registry result: %w", err)
+		}
+		if address := unpacked[0].(common.Address); address != (common.Address{}) {
+			return address, i == 0, nil
+		}
+	}
+	return common.Address{}, false, xerrors.Errorf("resolver of %s not found", name)
+}
+
+func supportsExtendedResolver(ctx context.Context, caller bind.ContractCaller, resolver common.Address) bool {
+	data, err := ensABI.Pack("supportsInterface", interfaceIDExtendedResolver)
+	if err != nil {
+		return false
+	}
+	result, err := call(ctx, caller, resolver, data)
+	if err != nil {
+		return false
+	}
+	unpacked, err := ensABI.Unpack("supportsInterface", result)
+	if err != nil {
+		return false
+	}
+	return unpacked[0].(bool)
+}
+
+// ccipCall calls `to`, following `OffchainLookup` reverts by querying
+// gateways and calling back with the gateway response.
+func ccipCall(ctx context.Context, caller bind.ContractCaller, to common.Address, data []byte) ([]byte, error) {
+	for i := 0; i <= MAX_CCIP_LOOKUPS; i++ {
+		result, err := call(ctx, caller, to, data)
+		if err == nil {
+			return result, nil
+		}
+		revert := revertData(err)
+		if !bytes.HasPrefix(revert, selectorOffchainLookup) {
+			return nil, err
+		}
+
+		lookup, err := parseOffchainLookup(revert[len(selectorOffchainLookup):])
+		if err != nil {
+			return nil, err
+		}
+		if lookup.Sender != to {
+			return nil, xerrors.Errorf("OffchainLookup sender mismatch: expect %s, got %s", to.Hex(), lookup.Sender.Hex())
+		}
+		response, err := requestGateway(ctx, lookup)
+		if err != nil {
+			return nil, err
+		}
+		args, err := callbackArgs.Pack(response, lookup.ExtraData)
+		if err != nil {
+			return nil, err
+		}
+		data = append(lookup.CallbackFunction[:], args...)
+	}
+	return nil, xerrors.Errorf("too many OffchainLookup of %s", to.Hex())
+}
+
+// requestGateway tries gateway URLs in order.
+// https://eips.ethereum.org/EIPS/eip-3668#gateway-interface
+func requestGateway(ctx context.Context, lookup *offchainLookup) ([]byte, error) {
+	sender := strings.ToLower(lookup.Sender.Hex())
+	callData := hexutil.Encode(lookup.CallData)
+	errs := make([]string, 0, len(lookup.URLs))
+	for _, template := range lookup.URLs {
+		gatewayURL := strings.ReplaceAll(template, "{sender}", sender)
+		var req *http.Request
+		var err error
+		if strings.Contains(gatewayURL, "{data}") {
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(gatewayURL, "{data}", callData), nil)
+		} else {
+			body, _ := json.Marshal(map[string]string{"data": callData, "sender": sender})
+			req, err = http.NewRequestWithContext(ctx, http.MethodPost, gatewayURL, bytes.NewReader(body))
+			if req != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		resp, err := ccipClient.Do(req)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		// 4xx means the gateway refuses this request. Do not try others.
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return nil, xerrors.Errorf("CCIP-read gateway %s returns %d: %s", template, resp.StatusCode, string(body))
+		}
+		if resp.StatusCode != http.StatusOK {
+			errs = append(errs, xerrors.Errorf("%s returns %d", template, resp.StatusCode).Error())
+			continue
+		}
+
+		gatewayResp := struct {
+			Data string `json:"data"`
+		}{}
+		if err := json.Unmarshal(body, &gatewayResp); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		return hexutil.Decode(gatewayResp.Data)
+	}
+	return nil, xerrors.Errorf("all CCIP-read gateways failed: %s", strings.Join(errs, "; "))
+}
+
+func parseOffchainLookup(data []byte) (*offchainLookup, error) {
+	unpacked, err := offchainLookupArgs.Unpack(data)
+	if err != nil {
+		return nil, xerrors.Errorf("error when decoding OffchainLookup: %w", err)
+	}
+	return &offchainLookup{
+		Sender:           unpacked[0].(common.Address),
+		URLs:             unpacked[1].([]string),
+		CallData:         unpacked[2].([]byte),
+		CallbackFunction: unpacked[3].([4]byte),
+		ExtraData:        unpacked[4].([]byte),
+	}, nil
+}
+
+// revertData extracts revert data from error of `eth_call`.
+func revertData(err error) []byte {
+	var dataErr interface{ ErrorData() interface{} }
+	if !xerrors.As(err, &dataErr) {
+		return nil
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil
+	}
+	data, err := hexutil.Decode(hexData)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// dnsEncode encodes `name` in DNS wire format, as `resolve()` requires.
+func dnsEncode(name string) ([]byte, error) {
+	encoded := []byte{}
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 255 {
+			return nil, xerrors.Errorf("invalid label in name %s", name)
+		}
+		encoded = append(encoded, byte(len(label)))
+		encoded = append(encoded, label...)
+	}
+	return append(encoded, 0), nil
+}
+
+func callAddress(ctx context.Context, caller bind.ContractCaller, to common.Address, method string, args ...interface{}) (common.Address, error) {
+	data, err := ensABI.Pack(method, args...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	result, err := call(ctx, caller, to, data)
+	if err != nil {
+		return common.Address{}, err
+	}
+	unpacked, err := ensABI.Unpack(method, result)
+	if err != nil {
+		return common.Address{}, xerrors.Errorf("error when decoding %s() result: %w", method, err)
+	}
+	return unpacked[0].(common.Address), nil
+}
+
+func call(ctx context.Context, caller bind.ContractCaller, to common.Address, data []byte) ([]byte, error) {
+	return caller.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func mustArguments(types ...string) abi.Arguments {
+	args := make(abi.Arguments, 0, len(types))
+	for _, t := range types {
+		abiType, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, abi.Argument{Type: abiType})
+	}
+	return args
+}
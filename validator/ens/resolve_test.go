@@ -0,0 +1,321 @@
+package ens
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+	ensv3 "github.com/wealdtech/go-ens/v3"
+)
+
+// stubBehavior of a function in minimal EVM contracts deployed on the
+// simulated chain: returns / reverts with a fixed blob, or echoes its
+// arguments back.
+type stubBehavior struct {
+	revert bool
+	echo   bool
+	data   []byte
+}
+
+const (
+	opSHR          = 0x1c
+	opEQ           = 0x14
+	opSUB          = 0x03
+	opCALLDATALOAD = 0x35
+	opCALLDATASIZE = 0x36
+	opCALLDATACOPY = 0x37
+	opCODECOPY     = 0x39
+	opSLOAD        = 0x54
+	opMSTORE       = 0x52
+	opJUMPI        = 0x57
+	opJUMPDEST     = 0x5b
+	opPUSH1        = 0x60
+	opPUSH2        = 0x61
+	opPUSH4        = 0x63
+	opDUP1         = 0x80
+	opRETURN       = 0xf3
+	opREVERT       = 0xfd
+)
+
+func push2(code []byte, value int) []byte {
+	return append(code, opPUSH2, byte(value>>8), byte(value))
+}
+
+// stubContract assembles a contract dispatching on function selector.
+// Assembled twice: jump destinations and blob offsets are known only
+// after the first pass.
+func stubContract(behaviors map[string]stubBehavior) []byte {
+	selectors := make([]string, 0, len(behaviors))
+	for selector := range behaviors {
+		selectors = append(selectors, selector)
+	}
+
+	assemble := func(dests map[string]int, dataOffset int) (code []byte, bodies map[string]int) {
+		bodies = map[string]int{}
+		code = []byte{opPUSH1, 0, opCALLDATALOAD, opPUSH1, 0xe0, opSHR}
+		for _, selector := range selectors {
+			code = append(code, opDUP1, opPUSH4)
+			code = append(code, hexutil.MustDecode(selector)...)
+			code = append(code, opEQ)
+			code = push2(code, dests[selector])
+			code = append(code, opJUMPI)
+		}
+		code = append(code, opPUSH1, 0, opDUP1, opREVERT)
+
+		data := []byte{}
+		for _, selector := range selectors {
+			behavior := behaviors[selector]
+			bodies[selector] = len(code)
+			code = append(code, opJUMPDEST)
+			if behavior.echo {
+				code = append(code, opPUSH1, 4, opCALLDATASIZE, opSUB, opPUSH1, 4, opPUSH1, 0, opCALLDATACOPY)
+				code = append(code, opPUSH1, 4, opCALLDATASIZE, opSUB, opPUSH1, 0, opRETURN)
+				continue
+			}
+			code = push2(code, len(behavior.data))
+			code = push2(code, dataOffset+len(data))
+			code = append(code, opPUSH1, 0, opCODECOPY)
+			code = push2(code, len(behavior.data))
+			code = append(code, opPUSH1, 0)
+			if behavior.revert {
+				code = append(code, opREVERT)
+			} else {
+				code = append(code, opRETURN)
+			}
+			data = append(data, behavior.data...)
+		}
+		return append(code, data...), bodies
+	}
+
+	draft, dests := assemble(map[string]int{}, 0)
+	dataLength := 0
+	for _, behavior := range behaviors {
+		dataLength += len(behavior.data)
+	}
+	code, _ := assemble(dests, len(draft)-dataLength)
+	return code
+}
+
+// registryContract returns storage slot keyed by the first argument,
+// i.e. `resolver(node)` reads `storage[node]`.
+func registryContract() []byte {
+	return []byte{opPUSH1, 4, opCALLDATALOAD, opSLOAD, opPUSH1, 0, opMSTORE, opPUSH1, 0x20, opPUSH1, 0, opRETURN}
+}
+
+func selectorOf(signature string) string {
+	return hexutil.Encode(ethcrypto.Keccak256([]byte(signature))[:4])
+}
+
+func encodeString(t *testing.T, value string) []byte {
+	encoded, err := ensABI.Methods["text"].Outputs.Pack(value)
+	require.NoError(t, err)
+	return encoded
+}
+
+func encodeBool(value bool) []byte {
+	encoded := make([]byte, 32)
+	if value {
+		encoded[31] = 1
+	}
+	return encoded
+}
+
+var (
+	registryAddress        = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	onchainResolverAddress = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	offchainResolverAddr   = common.HexToAddress("0x3000000000000000000000000000000000000003")
+)
+
+// simulatedChain deploys registry, an onchain resolver for
+// `alice.eth` (and, wrongly, parent of `bob.alice.eth`), and an
+// offchain wildcard resolver for `*.example.eth`.
+func simulatedChain(t *testing.T, record string, gatewayURL string) *backends.SimulatedBackend {
+	storage := map[common.Hash]common.Hash{}
+	for name, resolver := range map[string]common.Address{
+		"alice.eth":   onchainResolverAddress,
+		"example.eth": offchainResolverAddr,
+	} {
+		node, err := ensv3.NameHash(name)
+		require.NoError(t, err)
+		storage[common.Hash(node)] = common.BytesToHash(resolver.Bytes())
+	}
+
+	lookup, err := offchainLookupArgs.Pack(
+		offchainResolverAddr,
+		[]string{gatewayURL + "/{sender}/{data}.json"},
+		[]byte{0x12, 0x34},
+		[4]byte(hexutil.MustDecode(selectorOf("resolveWithProof(bytes,bytes)"))),
+		[]byte{0xab, 0xcd},
+	)
+	require.NoError(t, err)
+
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		registryAddress: {Code: registryContract(), Storage: storage, Balance: big.NewInt(0)},
+		onchainResolverAddress: {Code: stubContract(map[string]stubBehavior{
+			selectorOf("supportsInterface(bytes4)"): {data: encodeBool(false)},
+			selectorOf("text(bytes32,string)"):      {data: encodeString(t, record)},
+		}), Balance: big.NewInt(0)},
+		offchainResolverAddr: {Code: stubContract(map[string]stubBehavior{
+			selectorOf("supportsInterface(bytes4)"):     {data: encodeBool(true)},
+			selectorOf("resolve(bytes,bytes)"):          {revert: true, data: append(selectorOffchainLookup, lookup...)},
+			selectorOf("resolveWithProof(bytes,bytes)"): {echo: true},
+		}), Balance: big.NewInt(0)},
+	}, 8_000_000)
+	t.Cleanup(func() { sim.Close() })
+	return sim
+}
+
+// gatewayStub answers CCIP-read requests of offchain resolver with `record`.
+func gatewayStub(t *testing.T, record string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+strings.ToLower(offchainResolverAddr.Hex())+"/0x1234.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"data": hexutil.Encode(encodeString(t, record))})
+	}))
+}
+
+func useRegistry(t *testing.T, sim *backends.SimulatedBackend) {
+	originalRegistry, originalClient := REGISTRY, client
+	REGISTRY, client = registryAddress, sim
+	t.Cleanup(func() { REGISTRY, client = originalRegistry, originalClient })
+}
+
+func Test_ResolveText(t *testing.T) {
+	gateway := gatewayStub(t, "offchain record")
+	defer gateway.Close()
+	sim := simulatedChain(t, "onchain record", gateway.URL)
+	useRegistry(t, sim)
+
+	t.Run("onchain resolver", func(t *testing.T) {
+		text, err := ResolveText(context.Background(), sim, "alice.eth", ensKey)
+		require.NoError(t, err)
+		require.Equal(t, "onchain record", text)
+	})
+
+	t.Run("wildcard with CCIP-read", func(t *testing.T) {
+		text, err := ResolveText(context.Background(), sim, "sub.example.eth", ensKey)
+		require.NoError(t, err)
+		require.Equal(t, "offchain record", text)
+	})
+
+	t.Run("parent resolver without wildcard support", func(t *testing.T) {
+		_, err := ResolveText(context.Background(), sim, "bob.alice.eth", ensKey)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not support wildcard resolution")
+	})
+
+	t.Run("resolver not found", func(t *testing.T) {
+		_, err := ResolveText(context.Background(), sim, "nobody.xyz", ensKey)
+		require.Error(t, err)
+	})
+}
+
+func Test_Validate_offchain(t *testing.T) {
+	pk, sk := crypto.GenerateSecp256k1Keypair()
+	ens := build()
+	ens.Identity = "sub.example.eth"
+	ens.Pubkey = pk
+	sig, err := crypto.SignPersonal([]byte(ens.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	record := strings.ReplaceAll(ens.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+
+	gateway := gatewayStub(t, record)
+	defer gateway.Close()
+	useRegistry(t, simulatedChain(t, "", gateway.URL))
+
+	require.NoError(t, ens.Validate())
+	require.Equal(t, "sub.example.eth", ens.AltID)
+}
+
+func Test_dnsEncode(t *testing.T) {
+	encoded, err := dnsEncode("sub.example.eth")
+	require.NoError(t, err)
+	expected := []byte{3}
+	expected = append(expected, "sub"...)
+	expected = append(expected, 7)
+	expected = append(expected, "example"...)
+	expected = append(expected, 3)
+	expected = append(expected, "eth"...)
+	require.Equal(t, append(expected, 0), encoded)
+
+	_, err = dnsEncode("bad..eth")
+	require.Error(t, err)
+}
+
+func Test_ResolveOwner(t *testing.T) {
+	wallet := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	wrapper := common.HexToAddress("0x5000000000000000000000000000000000000005")
+	originalWrapper := NAME_WRAPPER
+	NAME_WRAPPER = wrapper
+	defer func() { NAME_WRAPPER = originalWrapper }()
+
+	ownerRegistry := func(owner common.Address) *backends.SimulatedBackend {
+		sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+			registryAddress: {Code: stubContract(map[string]stubBehavior{
+				selectorOf("owner(bytes32)"): {data: common.LeftPadBytes(owner.Bytes(), 32)},
+			}), Balance: big.NewInt(0)},
+			wrapper: {Code: stubContract(map[string]stubBehavior{
+				selectorOf("ownerOf(uint256)"): {data: common.LeftPadBytes(wallet.Bytes(), 32)},
+			}), Balance: big.NewInt(0)},
+		}, 8_000_000)
+		t.Cleanup(func() { sim.Close() })
+		useRegistry(t, sim)
+		return sim
+	}
+
+	t.Run("unwrapped name", func(t *testing.T) {
+		sim := ownerRegistry(wallet)
+		owner, err := ResolveOwner(context.Background(), sim, "alice.eth")
+		require.NoError(t, err)
+		require.Equal(t, wallet, owner)
+	})
+
+	t.Run("wrapped name", func(t *testing.T) {
+		sim := ownerRegistry(wrapper)
+		owner, err := ResolveOwner(context.Background(), sim, "alice.eth")
+		require.NoError(t, err)
+		require.Equal(t, wallet, owner)
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		sim := ownerRegistry(common.Address{})
+		_, err := ResolveOwner(context.Background(), sim, "alice.eth")
+		require.Error(t, err)
+	})
+}
+
+func Test_Validate_owner(t *testing.T) {
+	config.C.Platform.ENS.CheckOwner = true
+	defer func() { config.C.Platform.ENS.CheckOwner = false }()
+
+	pk, sk := crypto.GenerateSecp256k1Keypair()
+	ens := build()
+	ens.Identity = "alice.eth"
+	ens.Pubkey = pk
+	sig, err := crypto.SignPersonal([]byte(ens.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	record := strings.ReplaceAll(ens.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+
+	useRegistry(t, simulatedChain(t, record, ""))
+
+	// Registry in simulated chain answers `owner(node)` with resolver
+	// address stored for the node.
+	require.NoError(t, ens.Validate())
+	require.Equal(t, strings.ToLower(onchainResolverAddress.Hex()), ens.Extra[validator.EXTRA_OWNER])
+}
@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Evidence is what a validator fetched from platform while
+// validating, kept to settle disputes after the post is gone.
+type Evidence struct {
+	// Raw is the content as fetched (e.g. response body). `Base.Text`
+	// is used if empty.
+	Raw []byte
+	// Author is the post author told by platform.
+	Author string
+	// Fetcher tells how the content was fetched, if the validator has
+	// more than one way.
+	Fetcher string
+	// StatusCode of HTTP response. 0 if not fetched by HTTP.
+	StatusCode int
+	FetchedAt  time.Time
+	// Cached is true if the content was given from cache of headless
+	// service. `FetchedAt` is when it was rendered then.
+	Cached bool
+	// ScreenshotHash and HTMLHash are hashes of page captured by
+	// headless service, if any.
+	ScreenshotHash string
+	HTMLHash       string
+}
+
+// RecordEvidence keeps fetched content in `base.Evidence`.
+func (base *Base) RecordEvidence(raw []byte, author, fetcher string, statusCode int) {
+	base.Evidence = &Evidence{
+		Raw:        raw,
+		Author:     author,
+		Fetcher:    fetcher,
+		StatusCode: statusCode,
+		FetchedAt:  time.Now(),
+	}
+}
+
+// ResponseRecorder is a transport keeping body and status code of the
+// last response, for clients (e.g. SDKs of platforms) which do not
+// give them.
+type ResponseRecorder struct {
+	// Base is `http.DefaultTransport` if nil.
+	Base http.RoundTripper
+
+	mu         sync.Mutex
+	body       []byte
+	statusCode int
+}
+
+func (recorder *ResponseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := recorder.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.body, recorder.statusCode = body, resp.StatusCode
+	return resp, nil
+}
+
+// Last gives body and status code of the last response. Status code is
+// 0 if nothing responded.
+func (recorder *ResponseRecorder) Last() (body []byte, statusCode int) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.body, recorder.statusCode
+}
+
+// AttachHeadless keeps when `post` was rendered by headless browser,
+// and hashes of its capture (if any), in evidence.
+func (evidence *Evidence) AttachHeadless(post *HeadlessPost) {
+	if evidence == nil || post == nil {
+		return
+	}
+	if !post.FetchedAt.IsZero() {
+		evidence.FetchedAt = post.FetchedAt
+	}
+	evidence.Cached = post.Cached
+	if post.Capture != nil {
+		evidence.ScreenshotHash = post.Capture.ScreenshotHash
+		evidence.HTMLHash = post.Capture.HTMLHash
+	}
+}
+
+// GetEvidence gives evidence of last validation. Validators which do
+// not record one get an evidence made from `Text`. Nil if nothing was
+// fetched.
+func (base *Base) GetEvidence() *Evidence {
+	if base.Evidence != nil {
+		return base.Evidence
+	}
+	if base.Text == "" {
+		return nil
+	}
+	return &Evidence{FetchedAt: time.Now()}
+}
+
+// ContentHash is hex-encoded SHA256 of raw content (or `text` if no
+// raw content).
+func (evidence *Evidence) ContentHash(text string) string {
+	content := evidence.Raw
+	if len(content) == 0 {
+		content = []byte(text)
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// NormalizeText unifies line endings and trims trailing spaces of
+// each line, so that the same post fetched in different ways gives
+// the same text.
+func NormalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
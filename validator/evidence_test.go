@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nextdotid/proof_server/headless"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NormalizeText(t *testing.T) {
+	require.Equal(t, "Verify\nSig: abc", NormalizeText("  Verify  \r\nSig: abc\t\r\n\n"))
+	require.Equal(t, "", NormalizeText(" \n "))
+}
+
+func Test_GetEvidence(t *testing.T) {
+	base := Base{}
+	require.Nil(t, base.GetEvidence(), "nothing fetched")
+
+	base.Text = "Sig: abc"
+	evidence := base.GetEvidence()
+	require.NotNil(t, evidence)
+	// sha256("Sig: abc")
+	require.Equal(t, "bcf047f8e6144e8c1e1243f77b62dfd227f0841cfc85ee0995f011b0e9da7356", evidence.ContentHash(base.Text))
+
+	base.RecordEvidence([]byte(`{"text":"Sig: abc"}`), "yeiwb", "api", 200)
+	require.Equal(t, "yeiwb", base.GetEvidence().Author)
+	require.NotEqual(t, base.GetEvidence().ContentHash(base.Text), evidence.ContentHash(base.Text), "raw content is hashed if given")
+}
+
+func Test_AttachHeadless(t *testing.T) {
+	base := Base{}
+	base.Evidence.AttachHeadless(&HeadlessPost{Capture: &headless.Capture{ScreenshotHash: "a"}})
+	require.Nil(t, base.Evidence, "nothing fetched")
+
+	base.RecordEvidence(nil, "yeiwb", "headless", 0)
+	fetchedAt := base.Evidence.FetchedAt
+	base.Evidence.AttachHeadless(nil)
+	require.Equal(t, "", base.Evidence.ScreenshotHash)
+
+	rendered := time.Unix(1700000000, 0)
+	base.Evidence.AttachHeadless(&HeadlessPost{
+		Capture:   &headless.Capture{ScreenshotHash: "a", HTMLHash: "b"},
+		FetchedAt: rendered,
+		Cached:    true,
+	})
+	require.Equal(t, "a", base.GetEvidence().ScreenshotHash)
+	require.Equal(t, "b", base.GetEvidence().HTMLHash)
+	require.True(t, base.GetEvidence().Cached)
+	require.True(t, rendered.Equal(base.GetEvidence().FetchedAt))
+	require.False(t, rendered.Equal(fetchedAt), "time of rendering replaces time of recording")
+}
+
+func Test_ResponseRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	}))
+	defer ts.Close()
+	recorder := &ResponseRecorder{}
+	_, statusCode := recorder.Last()
+	require.Equal(t, 0, statusCode)
+
+	resp, err := (&http.Client{Transport: recorder}).Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"message":"Not Found"}`, string(body), "body is still readable")
+
+	raw, statusCode := recorder.Last()
+	require.Equal(t, http.StatusNotFound, statusCode)
+	require.Equal(t, body, raw)
+}
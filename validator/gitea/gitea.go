@@ -0,0 +1,198 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// Gitea validates accounts on Gitea / Forgejo instances (Codeberg
+// included). Identity should be `username@host`.
+//
+// Gitea has no gist-like API, so a public repository owned by the
+// user (given as `proof_location`) plays the role of a gist.
+type Gitea struct {
+	*validator.Base
+}
+
+type gistPayload struct {
+	Version       string `json:"version"`
+	Comment       string `json:"comment"`
+	Comment2      string `json:"comment2"`
+	Persona       string `json:"persona"`
+	GiteaUsername string `json:"gitea_username"`
+	SignPayload   string `json:"sign_payload"`
+	Signature     string `json:"signature"`
+	CreatedAt     string `json:"created_at"`
+	Uuid          string `json:"uuid"`
+}
+
+// https://try.gitea.io/api/swagger#/repository/repoGet
+type repositoryResponse struct {
+	ID      int64 `json:"id"`
+	Private bool  `json:"private"`
+	Owner   struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+var (
+	l = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "gitea"})
+	// API_TEMPLATE builds API entrypoint from instance host. Replaceable for testing.
+	API_TEMPLATE = "https://%s/api/v1"
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.Gitea] = func(base *validator.Base) validator.IValidator {
+		gt := Gitea{base}
+		return &gt
+	}
+}
+
+// SplitID splits identity into username and instance host.
+func (gt *Gitea) SplitID() (username, host string, err error) {
+	gt.Identity = strings.ToLower(strings.TrimPrefix(gt.Identity, "@"))
+	results := strings.Split(gt.Identity, "@")
+	if len(results) != 2 || results[0] == "" || results[1] == "" {
+		return "", "", xerrors.Errorf("invalid Gitea ID: should be username@host")
+	}
+	return results[0], results[1], nil
+}
+
+func (gt *Gitea) GeneratePostPayload() (post map[string]string) {
+	gt.Identity = strings.ToLower(gt.Identity)
+	payload := gistPayload{
+		Version:       "1",
+		Comment:       "Here's an NextID proof of this Gitea account.",
+		Comment2:      "To validate, base64.decode the signature, and recover pubkey from it using sign_payload with ethereum personal_sign algo.",
+		Persona:       "0x" + crypto.CompressedPubkeyHex(gt.Pubkey),
+		GiteaUsername: gt.Identity,
+		SignPayload:   gt.GenerateSignPayload(),
+		Signature:     "%SIG_BASE64%",
+		CreatedAt:     util.TimeToTimestampString(gt.CreatedAt),
+		Uuid:          gt.Uuid.String(),
+	}
+
+	payload_json, _ := json.MarshalIndent(payload, "", "\t")
+	return map[string]string{"default": string(payload_json)}
+}
+
+func (gt *Gitea) GenerateSignPayload() (payload string) {
+	gt.Identity = strings.ToLower(gt.Identity)
+	payloadStruct := validator.H{
+		"action":     string(gt.Action),
+		"identity":   gt.Identity,
+		"platform":   string(types.Platforms.Gitea),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(gt.CreatedAt),
+		"uuid":       gt.Uuid.String(),
+	}
+	if gt.Previous != "" {
+		payloadStruct["prev"] = gt.Previous
+	}
+
+	payload_bytes, _ := json.Marshal(payloadStruct)
+	return string(payload_bytes)
+}
+
+func (gt *Gitea) Validate() (err error) {
+	username, host, err := gt.SplitID()
+	if err != nil {
+		return err
+	}
+	gt.SignaturePayload = gt.GenerateSignPayload()
+
+	api := fmt.Sprintf(API_TEMPLATE, host)
+	repoPath := fmt.Sprintf("%s/%s", url.PathEscape(username), url.PathEscape(gt.ProofLocation))
+	repo := new(repositoryResponse)
+	raw, statusCode, err := getJSON(fmt.Sprintf("%s/repos/%s", api, repoPath), repo)
+	if statusCode != 0 {
+		gt.RecordEvidence(raw, repo.Owner.Login, "", statusCode)
+	}
+	if err != nil {
+		return xerrors.Errorf("error when fetching repository: %w", err)
+	}
+	if repo.Private {
+		return xerrors.Errorf("repository should be public")
+	}
+	if username != strings.ToLower(repo.Owner.Login) {
+		return xerrors.Errorf("repository owner mismatch: should be %s, but got %s", username, repo.Owner.Login)
+	}
+	gt.AltID = strconv.FormatInt(repo.Owner.ID, 10)
+
+	filename := fmt.Sprintf("0x%s.json", crypto.CompressedPubkeyHex(gt.Pubkey))
+	content, _, err := getRaw(fmt.Sprintf("%s/repos/%s/raw/%s", api, repoPath, filename))
+	if err != nil || content == "" {
+		return xerrors.Errorf("%s not found or empty", filename)
+	}
+	payload := gistPayload{}
+	err = json.Unmarshal([]byte(content), &payload)
+	if err != nil {
+		return xerrors.Errorf("error when parsing JSON: %w", err)
+	}
+
+	pubkey_recovered, err := crypto.StringToSecp256k1Pubkey(payload.Persona)
+	if err != nil {
+		return xerrors.Errorf("error when recovering pubkey: %w", err)
+	}
+	if !pubkey_recovered.Equal(gt.Pubkey) {
+		return xerrors.Errorf("persona mismatch: should be 0x%s, but got %s", crypto.CompressedPubkeyHex(gt.Pubkey), payload.Persona)
+	}
+	if payload.SignPayload != gt.SignaturePayload {
+		return xerrors.Errorf("sign payload mismatch")
+	}
+	signature, err := util.DecodeString(payload.Signature)
+	if err != nil {
+		return xerrors.Errorf("error when decoding signature: %w", err)
+	}
+	gt.Signature = signature
+	return crypto.ValidatePersonalSignature(gt.SignaturePayload, signature, gt.Pubkey)
+}
+
+func (gt *Gitea) GetAltID() string {
+	return gt.AltID
+}
+
+// getJSON decodes response of `url` into `result`, giving body and
+// status code of the response as well.
+func getJSON(url string, result any) ([]byte, int, error) {
+	body, statusCode, err := getRaw(url)
+	if err != nil {
+		return []byte(body), statusCode, err
+	}
+	return []byte(body), statusCode, json.Unmarshal([]byte(body), result)
+}
+
+// getRaw gives body and status code of response of `url`, fetched by
+// `validator.PublicClient`. Body is given along with error of non-200
+// status code.
+func getRaw(url string) (string, int, error) {
+	resp, err := validator.PublicClient.Get(url)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, validator.PUBLIC_FETCH_LIMIT))
+	if err != nil {
+		return "", resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return string(body), resp.StatusCode, xerrors.Errorf("status code %d", resp.StatusCode)
+	}
+	return string(body), resp.StatusCode, nil
+}
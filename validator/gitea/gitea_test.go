@@ -0,0 +1,139 @@
+package gitea
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func generate(pubkey *ecdsa.PublicKey, host string) Gitea {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return Gitea{
+		Base: &validator.Base{
+			Platform:      types.Platforms.Gitea,
+			Previous:      "",
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      "nykma@" + host,
+			ProofLocation: "nextid-proof",
+			CreatedAt:     created_at,
+			Uuid:          uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+		},
+	}
+}
+
+// mockServer serves a repository owned by `owner` containing a signed proof file.
+func mockServer(t *testing.T, owner string, sk *ecdsa.PrivateKey) *httptest.Server {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+
+	gt := generate(&sk.PublicKey, strings.TrimPrefix(ts.URL, "http://"))
+	payload := gistPayload{}
+	require.NoError(t, json.Unmarshal([]byte(gt.GeneratePostPayload()["default"]), &payload))
+	sig, err := crypto.SignPersonal([]byte(payload.SignPayload), sk)
+	require.NoError(t, err)
+	payload.Signature = base64.StdEncoding.EncodeToString(sig)
+	fileContent, _ := json.Marshal(payload)
+	filename := fmt.Sprintf("0x%s.json", crypto.CompressedPubkeyHex(gt.Pubkey))
+
+	mux.HandleFunc("/api/v1/repos/nykma/nextid-proof", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":42,"private":false,"owner":{"id":114514,"login":"%s"}}`, owner)
+	})
+	mux.HandleFunc("/api/v1/repos/nykma/nextid-proof/raw/"+filename, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fileContent)
+	})
+	return ts
+}
+
+func Test_SplitID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		gt := Gitea{Base: &validator.Base{Identity: "@Nykma@Codeberg.org"}}
+		username, host, err := gt.SplitID()
+		require.NoError(t, err)
+		require.Equal(t, "nykma", username)
+		require.Equal(t, "codeberg.org", host)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gt := Gitea{Base: &validator.Base{Identity: "nykma"}}
+		_, _, err := gt.SplitID()
+		require.Error(t, err)
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	API_TEMPLATE = "http://%s/api/v1"
+	// Mock server is on loopback.
+	original := validator.PublicClient
+	validator.PublicClient = http.DefaultClient
+	t.Cleanup(func() { validator.PublicClient = original })
+
+	t.Run("success", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "Nykma", sk)
+		defer ts.Close()
+
+		gt := generate(&sk.PublicKey, strings.TrimPrefix(ts.URL, "http://"))
+		require.NoError(t, gt.Validate())
+		require.Equal(t, "114514", gt.AltID)
+	})
+
+	t.Run("error if owner mismatch", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "foobar", sk)
+		defer ts.Close()
+
+		gt := generate(&sk.PublicKey, strings.TrimPrefix(ts.URL, "http://"))
+		err := gt.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "repository owner mismatch")
+	})
+
+	t.Run("error if proof file not found", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "nykma", sk)
+		defer ts.Close()
+
+		other, _ := crypto.GenerateSecp256k1Keypair()
+		gt := generate(other, strings.TrimPrefix(ts.URL, "http://"))
+		err := gt.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found or empty")
+	})
+
+	t.Run("error if proof file is of another proof", func(t *testing.T) {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "nykma", sk)
+		defer ts.Close()
+
+		gt := generate(&sk.PublicKey, strings.TrimPrefix(ts.URL, "http://"))
+		gt.Uuid = uuid.New()
+		err := gt.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign payload mismatch")
+	})
+
+	t.Run("error if host is private", func(t *testing.T) {
+		validator.PublicClient = original
+		defer func() { validator.PublicClient = http.DefaultClient }()
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "nykma", sk)
+		defer ts.Close()
+
+		gt := generate(&sk.PublicKey, strings.TrimPrefix(ts.URL, "http://"))
+		err := gt.Validate()
+		require.ErrorIs(t, err, validator.ErrPrivateAddress)
+	})
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/nextdotid/proof_server/config"
 	"github.com/nextdotid/proof_server/types"
 	"github.com/nextdotid/proof_server/util"
 	"github.com/nextdotid/proof_server/util/crypto"
@@ -87,8 +89,12 @@ func (gh *Github) Validate() (err error) {
 	gh.Identity = strings.ToLower(gh.Identity)
 	gh.SignaturePayload = gh.GenerateSignPayload()
 
-	client := ghub.NewClient(nil)
+	recorder := &validator.ResponseRecorder{}
+	client := newClient(recorder)
 	gist, response, err := client.Gists.Get(context.TODO(), gh.ProofLocation)
+	if raw, statusCode := recorder.Last(); statusCode != 0 {
+		gh.RecordEvidence(raw, gist.GetOwner().GetLogin(), "", statusCode)
+	}
 	if err != nil {
 		return xerrors.Errorf("error when fetching gist: %w", err)
 	}
@@ -97,10 +103,17 @@ func (gh *Github) Validate() (err error) {
 		return xerrors.Errorf("error when fetching gist")
 	}
 
-	if gh.Identity != gist.Owner.GetLogin() {
-		return xerrors.Errorf("gist owner mismatch: should be %s, but got %s", gh.Identity, gist.Owner.GetLogin())
+	ownerID := strconv.FormatInt(gist.Owner.GetID(), 10)
+	login := strings.ToLower(gist.Owner.GetLogin())
+	renamed := false
+	if gh.Identity != login {
+		// Known user (by user ID) with a new login.
+		if gh.AltID == "" || gh.AltID != ownerID {
+			return xerrors.Errorf("gist owner mismatch: should be %s, but got %s", gh.Identity, gist.Owner.GetLogin())
+		}
+		renamed = true
 	}
-	gh.AltID = strconv.FormatInt(gist.Owner.GetID(), 10)
+	gh.AltID = ownerID
 
 	gist_filename := fmt.Sprintf("0x%s.json", crypto.CompressedPubkeyHex(gh.Pubkey))
 	files := gist.GetFiles()
@@ -115,6 +128,7 @@ func (gh *Github) Validate() (err error) {
 	if content == "" {
 		return xerrors.Errorf("%s not found or empty", gist_filename)
 	}
+	gh.Text = content
 	payload := gistPayload{}
 	err = json.Unmarshal([]byte(content), &payload)
 	if err != nil {
@@ -129,9 +143,27 @@ func (gh *Github) Validate() (err error) {
 	if err != nil {
 		return xerrors.Errorf("error when decoding signature: %w", err)
 	}
-	return crypto.ValidatePersonalSignature(payload.SignPayload, signature, pubkey_recovered)
+	if err := crypto.ValidatePersonalSignature(payload.SignPayload, signature, pubkey_recovered); err != nil {
+		return err
+	}
+	if renamed {
+		l.Infof("user %s renamed from %s to %s", gh.AltID, gh.Identity, login)
+		gh.Identity = login
+	}
+	return nil
 }
 
 func (gh *Github) GetAltID() string {
 	return gh.AltID
 }
+
+// newClient gives a client authenticated by tokens from pool, or an
+// anonymous one if no token configured. Responses are kept by
+// `recorder`.
+func newClient(recorder *validator.ResponseRecorder) *ghub.Client {
+	pool := validator.GetTokenPool(types.Platforms.Github, config.C.Platform.Github.Tokens)
+	if pool.Len() == 0 {
+		return ghub.NewClient(&http.Client{Transport: recorder})
+	}
+	return ghub.NewClient(&http.Client{Transport: &validator.TokenTransport{Pool: pool, Base: recorder}})
+}
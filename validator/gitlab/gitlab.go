@@ -0,0 +1,192 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+type GitLab struct {
+	*validator.Base
+}
+
+type snippetPayload struct {
+	Version        string `json:"version"`
+	Comment        string `json:"comment"`
+	Comment2       string `json:"comment2"`
+	Persona        string `json:"persona"`
+	GitLabUsername string `json:"gitlab_username"`
+	SignPayload    string `json:"sign_payload"`
+	Signature      string `json:"signature"`
+	CreatedAt      string `json:"created_at"`
+	Uuid           string `json:"uuid"`
+}
+
+// https://docs.gitlab.com/ee/api/snippets.html#single-snippet
+type snippetResponse struct {
+	ID         int64  `json:"id"`
+	Visibility string `json:"visibility"`
+	Author     struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"author"`
+	Files []struct {
+		Path   string `json:"path"`
+		RawURL string `json:"raw_url"`
+	} `json:"files"`
+}
+
+var (
+	l = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "gitlab"})
+	// API_BASE is the GitLab REST API v4 entrypoint. Replaceable for testing.
+	API_BASE = "https://gitlab.com/api/v4"
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.GitLab] = func(base *validator.Base) validator.IValidator {
+		gl := GitLab{base}
+		return &gl
+	}
+}
+
+func (gl *GitLab) GeneratePostPayload() (post map[string]string) {
+	gl.Identity = strings.ToLower(gl.Identity)
+	payload := snippetPayload{
+		Version:        "1",
+		Comment:        "Here's an NextID proof of this GitLab account.",
+		Comment2:       "To validate, base64.decode the signature, and recover pubkey from it using sign_payload with ethereum personal_sign algo.",
+		Persona:        "0x" + crypto.CompressedPubkeyHex(gl.Pubkey),
+		GitLabUsername: gl.Identity,
+		SignPayload:    gl.GenerateSignPayload(),
+		Signature:      "%SIG_BASE64%",
+		CreatedAt:      util.TimeToTimestampString(gl.CreatedAt),
+		Uuid:           gl.Uuid.String(),
+	}
+
+	payload_json, _ := json.MarshalIndent(payload, "", "\t")
+	return map[string]string{"default": string(payload_json)}
+}
+
+func (gl *GitLab) GenerateSignPayload() (payload string) {
+	gl.Identity = strings.ToLower(gl.Identity)
+	payloadStruct := validator.H{
+		"action":     string(gl.Action),
+		"identity":   gl.Identity,
+		"platform":   string(types.Platforms.GitLab),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(gl.CreatedAt),
+		"uuid":       gl.Uuid.String(),
+	}
+	if gl.Previous != "" {
+		payloadStruct["prev"] = gl.Previous
+	}
+
+	payload_bytes, _ := json.Marshal(payloadStruct)
+	return string(payload_bytes)
+}
+
+func (gl *GitLab) Validate() (err error) {
+	gl.Identity = strings.ToLower(gl.Identity)
+	gl.SignaturePayload = gl.GenerateSignPayload()
+
+	snippet := new(snippetResponse)
+	raw, statusCode, err := getJSON(fmt.Sprintf("%s/snippets/%s", API_BASE, url.PathEscape(gl.ProofLocation)), snippet)
+	if statusCode != 0 {
+		gl.RecordEvidence(raw, snippet.Author.Username, "", statusCode)
+	}
+	if err != nil {
+		return xerrors.Errorf("error when fetching snippet: %w", err)
+	}
+	if snippet.Visibility != "" && snippet.Visibility != "public" {
+		return xerrors.Errorf("snippet should be public, got %s", snippet.Visibility)
+	}
+	if gl.Identity != strings.ToLower(snippet.Author.Username) {
+		return xerrors.Errorf("snippet owner mismatch: should be %s, but got %s", gl.Identity, snippet.Author.Username)
+	}
+	gl.AltID = strconv.FormatInt(snippet.Author.ID, 10)
+
+	snippet_filename := fmt.Sprintf("0x%s.json", crypto.CompressedPubkeyHex(gl.Pubkey))
+	content := ""
+	for _, file := range snippet.Files {
+		if file.Path != snippet_filename {
+			continue
+		}
+
+		content, _, err = getRaw(file.RawURL)
+		if err != nil {
+			return xerrors.Errorf("error when fetching snippet file: %w", err)
+		}
+	}
+	if content == "" {
+		return xerrors.Errorf("%s not found or empty", snippet_filename)
+	}
+	payload := snippetPayload{}
+	err = json.Unmarshal([]byte(content), &payload)
+	if err != nil {
+		return xerrors.Errorf("error when parsing JSON: %w", err)
+	}
+
+	pubkey_recovered, err := crypto.StringToSecp256k1Pubkey(payload.Persona)
+	if err != nil {
+		return xerrors.Errorf("error when recovering pubkey: %w", err)
+	}
+	if !pubkey_recovered.Equal(gl.Pubkey) {
+		return xerrors.Errorf("persona mismatch: should be 0x%s, but got %s", crypto.CompressedPubkeyHex(gl.Pubkey), payload.Persona)
+	}
+	if payload.SignPayload != gl.SignaturePayload {
+		return xerrors.Errorf("sign payload mismatch")
+	}
+	signature, err := util.DecodeString(payload.Signature)
+	if err != nil {
+		return xerrors.Errorf("error when decoding signature: %w", err)
+	}
+	gl.Signature = signature
+	return crypto.ValidatePersonalSignature(gl.SignaturePayload, signature, gl.Pubkey)
+}
+
+func (gl *GitLab) GetAltID() string {
+	return gl.AltID
+}
+
+// getJSON decodes response of `url` into `result`, giving body and
+// status code of the response as well.
+func getJSON(url string, result any) ([]byte, int, error) {
+	body, statusCode, err := getRaw(url)
+	if err != nil {
+		return []byte(body), statusCode, err
+	}
+	return []byte(body), statusCode, json.Unmarshal([]byte(body), result)
+}
+
+// getRaw gives body and status code of response of `url`, fetched by
+// `validator.PublicClient`. Body is given along with error of non-200
+// status code.
+func getRaw(url string) (string, int, error) {
+	resp, err := validator.PublicClient.Get(url)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, validator.PUBLIC_FETCH_LIMIT))
+	if err != nil {
+		return "", resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return string(body), resp.StatusCode, xerrors.Errorf("status code %d", resp.StatusCode)
+	}
+	return string(body), resp.StatusCode, nil
+}
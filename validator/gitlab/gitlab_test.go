@@ -0,0 +1,115 @@
+package gitlab
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	"github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func generate(pubkey *ecdsa.PublicKey) GitLab {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return GitLab{
+		Base: &validator.Base{
+			Platform:      types.Platforms.GitLab,
+			Previous:      "",
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      "nykma",
+			ProofLocation: "2543210",
+			CreatedAt:     created_at,
+			Uuid:          uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+		},
+	}
+}
+
+// mockServer serves a snippet owned by `owner` containing a signed proof file.
+func mockServer(t *testing.T, owner string, sk *ecdsa.PrivateKey, gl GitLab) *httptest.Server {
+	post := gl.GeneratePostPayload()["default"]
+	payload := snippetPayload{}
+	require.NoError(t, json.Unmarshal([]byte(post), &payload))
+	sig, err := crypto.SignPersonal([]byte(payload.SignPayload), sk)
+	require.NoError(t, err)
+	payload.Signature = base64.StdEncoding.EncodeToString(sig)
+	fileContent, _ := json.Marshal(payload)
+	filename := fmt.Sprintf("0x%s.json", crypto.CompressedPubkeyHex(gl.Pubkey))
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	mux.HandleFunc("/snippets/2543210", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":2543210,"visibility":"public","author":{"id":1191636,"username":"%s"},"files":[{"path":"%s","raw_url":"%s/raw/%s"}]}`,
+			owner, filename, ts.URL, filename)
+	})
+	mux.HandleFunc("/raw/"+filename, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fileContent)
+	})
+	return ts
+}
+
+func Test_Validate(t *testing.T) {
+	// Mock server is on loopback.
+	original := validator.PublicClient
+	validator.PublicClient = http.DefaultClient
+	t.Cleanup(func() { validator.PublicClient = original })
+
+	t.Run("success", func(t *testing.T) {
+		pk, sk := crypto.GenerateSecp256k1Keypair()
+		gl := generate(pk)
+		ts := mockServer(t, "Nykma", sk, gl)
+		defer ts.Close()
+		API_BASE = ts.URL
+
+		require.NoError(t, gl.Validate())
+		require.Equal(t, "1191636", gl.AltID)
+		require.NotEmpty(t, gl.Signature)
+	})
+
+	t.Run("error if owner mismatch", func(t *testing.T) {
+		pk, sk := crypto.GenerateSecp256k1Keypair()
+		gl := generate(pk)
+		ts := mockServer(t, "foobar", sk, gl)
+		defer ts.Close()
+		API_BASE = ts.URL
+
+		err := gl.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "snippet owner mismatch")
+	})
+
+	t.Run("error if proof file not found", func(t *testing.T) {
+		pk, sk := crypto.GenerateSecp256k1Keypair()
+		gl := generate(pk)
+		ts := mockServer(t, "nykma", sk, gl)
+		defer ts.Close()
+		API_BASE = ts.URL
+
+		other, _ := crypto.GenerateSecp256k1Keypair()
+		gl.Pubkey = other
+		err := gl.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found or empty")
+	})
+
+	t.Run("error if proof file is of another proof", func(t *testing.T) {
+		pk, sk := crypto.GenerateSecp256k1Keypair()
+		gl := generate(pk)
+		ts := mockServer(t, "nykma", sk, gl)
+		defer ts.Close()
+		API_BASE = ts.URL
+
+		gl.Uuid = uuid.New()
+		err := gl.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign payload mismatch")
+	})
+}
@@ -100,13 +100,16 @@ func (kb *Keybase) Validate() (err error) {
 	if err != nil {
 		return xerrors.Errorf("Error when requesting proof: %s", err.Error())
 	}
-	if resp.StatusCode != 200 {
-		return xerrors.Errorf("Error when requesting proof: Status code %d", resp.StatusCode)
-	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return xerrors.Errorf("Error when getting resp body")
 	}
+	// Proof is served under the user's own public folder.
+	kb.RecordEvidence(body, kb.Identity, "", resp.StatusCode)
+	if resp.StatusCode != 200 {
+		return xerrors.Errorf("Error when requesting proof: Status code %d", resp.StatusCode)
+	}
 
 	payload := new(KeybasePayload)
 	err = json.Unmarshal(body, payload)
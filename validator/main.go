@@ -1,25 +1,46 @@
 package validator
 
 import (
-	"bytes"
+	"context"
 	"crypto/ecdsa"
-	"encoding/json"
-	"net/http"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-faster/errors"
 	"github.com/google/uuid"
 	"github.com/nextdotid/proof_server/config"
 	"github.com/nextdotid/proof_server/headless"
 	"github.com/nextdotid/proof_server/types"
 	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
 )
 
 var (
 	// PlatformFactories contains all supported platform factory.
 	PlatformFactories map[types.Platform]func(*Base) IValidator
+	// IdentityNormalizers give identities (or alt IDs) stored that a
+	// query of `identity` matches, for platforms keeping some of them
+	// in original case. Others are stored lowercased.
+	IdentityNormalizers = map[types.Platform]func(identity string) []string{}
+
+	headlessClient     *headless.HeadlessClient
+	headlessClientOnce sync.Once
 )
 
+// IdentityCandidates gives values of `identity` column a query of
+// `identity` on `platform` should match exactly. Both given and
+// lowercased form if `platform` is unknown.
+func IdentityCandidates(platform types.Platform, identity string) []string {
+	identity = strings.TrimSpace(identity)
+	if platform == "" {
+		return lo.Uniq([]string{identity, strings.ToLower(identity)})
+	}
+	if normalize, ok := IdentityNormalizers[platform]; ok {
+		return normalize(identity)
+	}
+	return []string{strings.ToLower(identity)}
+}
+
 type IValidator interface {
 	// GeneratePostPayload gives a post structure (with
 	// placeholders) for user to post on target platform.
@@ -52,6 +73,8 @@ type Base struct {
 	// third-party service distinguish / store / dedup links with
 	// ease.
 	Uuid uuid.UUID
+	// Evidence is set by validators which fetch proof post.
+	Evidence *Evidence
 }
 
 // BaseToInterface converts a `validator.Base` struct to
@@ -65,12 +88,55 @@ func BaseToInterface(v *Base) IValidator {
 	return performer_factory(v)
 }
 
-func GetPostWithHeadlessBrowser(url string, regexp string) (post string, err error) {
-	headlessEntrypoint := lo.Sample(config.C.Headless.Urls)
-	headlessEntrypoint += "/v1/find"
+// GetHeadlessClient gives the client of headless services in config,
+// created at first call.
+func GetHeadlessClient() *headless.HeadlessClient {
+	headlessClientOnce.Do(func() {
+		c := config.C.Headless
+		headlessClient = headless.NewHeadlessClient(c.Urls, headless.ClientOptions{
+			Secret:  c.Secret,
+			Timeout: parseDuration("headless.request_timeout", c.RequestTimeout),
+			Retries: c.Retries,
+		})
+		headlessClient.StartHealthCheck(context.Background(), parseDuration("headless.health_check_interval", c.HealthCheckInterval))
+	})
+	return headlessClient
+}
+
+// HeadlessHealth gives status of headless services, or nil if none
+// configured.
+func HeadlessHealth() []headless.EndpointHealth {
+	if len(config.C.Headless.Urls) == 0 {
+		return nil
+	}
+	return GetHeadlessClient().Health()
+}
+
+// HeadlessPost is a post found in page by headless browser.
+type HeadlessPost struct {
+	// Text matched.
+	Text string
+	// Extracted are results of extractions, keyed by name.
+	Extracted map[string][]string
+	Capture   *headless.Capture
+	// FetchedAt is when the page was rendered, and Cached tells if it
+	// was given from cache of headless service.
+	FetchedAt time.Time
+	Cached    bool
+}
+
+// GetPostWithHeadlessBrowser finds text matching `regexp` in page of
+// `url`, and runs `extractions` (e.g. for author of post) on the same
+// page. If `capture` is true, screenshot and DOM of the page are
+// captured by headless service.
+func GetPostWithHeadlessBrowser(ctx context.Context, url string, regexp string, extractions map[string]headless.Match, capture bool) (*HeadlessPost, error) {
+	timeout := config.C.Headless.MatchTimeout
+	if timeout == "" {
+		timeout = headless.DefaultMatchTimeout
+	}
 	request := headless.FindRequest{
 		Location: url,
-		Timeout:  "5s",
+		Timeout:  timeout,
 		Match: headless.Match{
 			Type: "regexp",
 			MatchRegExp: &headless.MatchRegExp{
@@ -80,28 +146,72 @@ func GetPostWithHeadlessBrowser(url string, regexp string) (post string, err err
 			MatchXPath: nil,
 			MatchJS:    nil,
 		},
-		WaitXHR: false,
+		WaitXHR:     false,
+		Extractions: extractions,
+		Profile:     config.C.Headless.Profile,
 	}
-	// POST request body to entrypoint headless server
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return "", err
+	if capture {
+		request.Capture = &headless.CaptureRequest{Screenshot: true, HTML: true}
 	}
-	respRaw, err := http.Post(headlessEntrypoint, "application/json", bytes.NewBuffer(requestBody))
+	response, err := GetHeadlessClient().Find(ctx, &request)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer respRaw.Body.Close()
-	response := headless.FindRespond{}
-	err = json.NewDecoder(respRaw.Body).Decode(&response)
+
+	return &HeadlessPost{
+		Text:      response.Content,
+		Extracted: response.Results,
+		Capture:   keepCapture(response.Capture),
+		FetchedAt: response.FetchedAt,
+		Cached:    response.Cached,
+	}, nil
+}
+
+// keepCapture makes sure content of `captured` is kept, by headless
+// service or in `headless.capture_dir`. Nil if kept nowhere, so that
+// evidence never points at content which does not exist.
+func keepCapture(captured *headless.Capture) *headless.Capture {
+	if captured == nil || captured.Stored {
+		return captured
+	}
+	dir := config.C.Headless.CaptureDir
+	if dir == "" {
+		logrus.Warnf("capture is not stored by headless service, and headless.capture_dir is not set")
+		return nil
+	}
+	storage, err := headless.NewDirStorage(dir)
 	if err != nil {
-		return "", err
+		logrus.Warnf("keeping capture: %s", err.Error())
+		return nil
 	}
-	if response.Message != "" {
-		return "", errors.Errorf("Error when fetching post from headless browser: %s", response.Message)
+	kept := *captured
+	if err := kept.StoreInline(storage); err != nil {
+		logrus.Warnf("keeping capture: %s", err.Error())
+		return nil
+	}
+	// Cached results of headless service come without content, which
+	// should have been kept when first rendered.
+	if (kept.ScreenshotHash != "" && !storage.Has(kept.ScreenshotHash, "png")) ||
+		(kept.HTMLHash != "" && !storage.Has(kept.HTMLHash, "html")) {
+		logrus.Warnf("capture content not found in headless.capture_dir")
+		return nil
 	}
+	kept.Stored = true
+	return &kept
+}
 
-	return response.Content, nil
+// parseDuration gives 0 (i.e. default) if `value` of config `key` is
+// empty or invalid.
+func parseDuration(key, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.Warnf("invalid %s: %s, using default", key, value)
+		return 0
+	}
+	return d
 }
 
 // H for JSON builder.
@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/headless"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keepCapture(t *testing.T) {
+	original := config.C.Headless.CaptureDir
+	t.Cleanup(func() { config.C.Headless.CaptureDir = original })
+	sum := sha256.Sum256([]byte("<html></html>"))
+	hash := hex.EncodeToString(sum[:])
+	inline := func() *headless.Capture {
+		return &headless.Capture{HTMLHash: hash, HTML: base64.StdEncoding.EncodeToString([]byte("<html></html>"))}
+	}
+
+	stored := &headless.Capture{HTMLHash: hash, Stored: true}
+	require.Equal(t, stored, keepCapture(stored), "kept by headless service")
+	require.Nil(t, keepCapture(nil))
+
+	config.C.Headless.CaptureDir = ""
+	require.Nil(t, keepCapture(inline()), "kept nowhere")
+
+	config.C.Headless.CaptureDir = t.TempDir()
+	require.Nil(t, keepCapture(&headless.Capture{HTMLHash: hash}), "cached without content, never kept")
+
+	kept := keepCapture(inline())
+	require.Equal(t, &headless.Capture{HTMLHash: hash, Stored: true}, kept)
+	require.Equal(t, kept, keepCapture(&headless.Capture{HTMLHash: hash}), "cached without content, kept before")
+}
+
+func Test_IdentityCandidates(t *testing.T) {
+	original := IdentityNormalizers
+	t.Cleanup(func() { IdentityNormalizers = original })
+	IdentityNormalizers = map[types.Platform]func(string) []string{
+		types.Platforms.YouTube: func(identity string) []string { return []string{identity} },
+	}
+
+	require.Equal(t, []string{"yeiwb"}, IdentityCandidates(types.Platforms.Twitter, " YeiWB "))
+	require.Equal(t, []string{"UCxyzABC"}, IdentityCandidates(types.Platforms.YouTube, "UCxyzABC"))
+	require.Equal(t, []string{"UCxyzABC", "ucxyzabc"}, IdentityCandidates("", "UCxyzABC"))
+}
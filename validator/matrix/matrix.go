@@ -0,0 +1,230 @@
+package matrix
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// Matrix validates a Matrix user ID (`@user:server`) by an event
+// containing `Sig:` line, sent by the user. The event could be a
+// message in configured public room (`proof_location` is
+// `$event_id`), or a state event in user's own room
+// (`proof_location` is `!room_id:server/$event_id`).
+type Matrix struct {
+	*validator.Base
+}
+
+const (
+	// DEFAULT_HOMESERVER is used when `platform.matrix.homeserver` is not set.
+	DEFAULT_HOMESERVER = "https://matrix-client.matrix.org"
+	// STATE_EVENT_TYPE is the event type of proof set as room state.
+	STATE_EVENT_TYPE = "id.next.proof"
+	MATCH_TEMPLATE   = "^Sig: (.+?)$"
+)
+
+var (
+	l           = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "matrix"})
+	re          = regexp.MustCompile(MATCH_TEMPLATE)
+	userIDRe    = regexp.MustCompile(`^@[a-z0-9._=/+-]+:[a-z0-9.-]+(:[0-9]+)?$`)
+	POST_STRUCT = map[string]string{
+		"default": "🎭 Verifying my Matrix ID %s for @NextDotID.\nSig: %%SIG_BASE64%%\n\nPowered by Next.ID - Connect All Digital Identities.\n",
+		"en_US":   "🎭 Verifying my Matrix ID %s for @NextDotID.\nSig: %%SIG_BASE64%%\n\nPowered by Next.ID - Connect All Digital Identities.\n",
+		"zh_CN":   "🎭 正在通过 @NextDotID 验证我的 Matrix 帐号 %s 。\nSig: %%SIG_BASE64%%\n\n由 Next.ID 支持 - 连接全域数字身份。\n",
+	}
+)
+
+// https://spec.matrix.org/v1.9/client-server-api/#get_matrixclientv3roomsroomideventeventid
+type event struct {
+	EventID  string  `json:"event_id"`
+	RoomID   string  `json:"room_id"`
+	Sender   string  `json:"sender"`
+	Type     string  `json:"type"`
+	StateKey *string `json:"state_key"`
+	Content  struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+type errorResponse struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.Matrix] = func(base *validator.Base) validator.IValidator {
+		matrix := Matrix{base}
+		return &matrix
+	}
+}
+
+func (matrix *Matrix) GeneratePostPayload() (post map[string]string) {
+	matrix.Identity = NormalizeUserID(matrix.Identity)
+	post = make(map[string]string)
+	for langCode, template := range POST_STRUCT {
+		post[langCode] = fmt.Sprintf(template, matrix.Identity)
+	}
+	return post
+}
+
+func (matrix *Matrix) GenerateSignPayload() (payload string) {
+	matrix.Identity = NormalizeUserID(matrix.Identity)
+	payloadStruct := validator.H{
+		"action":     string(matrix.Action),
+		"identity":   matrix.Identity,
+		"platform":   string(types.Platforms.Matrix),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(matrix.CreatedAt),
+		"uuid":       matrix.Uuid.String(),
+	}
+	if matrix.Previous != "" {
+		payloadStruct["prev"] = matrix.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshaling struct: %s", err.Error())
+		return ""
+	}
+
+	return string(payloadBytes)
+}
+
+func (matrix *Matrix) Validate() (err error) {
+	matrix.Identity = NormalizeUserID(matrix.Identity)
+	if !userIDRe.MatchString(matrix.Identity) {
+		return xerrors.Errorf("invalid matrix user ID: %s", matrix.Identity)
+	}
+	// Matrix user ID cannot be changed.
+	matrix.AltID = matrix.Identity
+	matrix.SignaturePayload = matrix.GenerateSignPayload()
+
+	// Deletion. No need to fetch the event.
+	if matrix.Action == types.Actions.Delete {
+		return mycrypto.ValidatePersonalSignature(matrix.SignaturePayload, matrix.Signature, matrix.Pubkey)
+	}
+
+	roomID, eventID, err := ParseProofLocation(matrix.ProofLocation)
+	if err != nil {
+		return err
+	}
+	ev, err := matrix.fetchEvent(roomID, eventID)
+	if err != nil {
+		return err
+	}
+	if ev.EventID != eventID || (ev.RoomID != "" && ev.RoomID != roomID) {
+		return xerrors.Errorf("event mismatch: expect %s in %s, got %s in %s", eventID, roomID, ev.EventID, ev.RoomID)
+	}
+	if strings.ToLower(ev.Sender) != matrix.Identity {
+		return xerrors.Errorf("sender mismatch: expect %s, got %s", matrix.Identity, ev.Sender)
+	}
+	// Outside of the public room, only proof state event is accepted.
+	if roomID != config.C.Platform.Matrix.PublicRoomID && (ev.Type != STATE_EVENT_TYPE || ev.StateKey == nil) {
+		return xerrors.Errorf("event type %s is not accepted outside of public room", ev.Type)
+	}
+
+	matrix.Text = ev.Content.Body
+	return matrix.validateText()
+}
+
+func (matrix *Matrix) GetAltID() string {
+	return matrix.AltID
+}
+
+// NormalizeUserID lowercases user ID, and prepends `@` if missing.
+func NormalizeUserID(userID string) string {
+	return "@" + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(userID)), "@")
+}
+
+// ParseProofLocation gives room ID and event ID of `proof_location`.
+// Room ID defaults to configured public room if not given.
+func ParseProofLocation(location string) (roomID, eventID string, err error) {
+	location = strings.TrimSpace(location)
+	roomID = config.C.Platform.Matrix.PublicRoomID
+	if index := strings.Index(location, "/$"); index >= 0 {
+		roomID, location = location[:index], location[index+1:]
+	}
+	if !strings.HasPrefix(roomID, "!") {
+		return "", "", xerrors.Errorf("room ID not found in proof location: %s", location)
+	}
+	if !strings.HasPrefix(location, "$") || len(location) < 2 {
+		return "", "", xerrors.Errorf("event ID not found in proof location: %s", location)
+	}
+	return roomID, location, nil
+}
+
+// fetchEvent gives event of `eventID` in `roomID`, keeping the
+// response as evidence.
+func (matrix *Matrix) fetchEvent(roomID, eventID string) (*event, error) {
+	homeserver := config.C.Platform.Matrix.Homeserver
+	if homeserver == "" {
+		homeserver = DEFAULT_HOMESERVER
+	}
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/event/%s",
+		strings.TrimSuffix(homeserver, "/"), url.PathEscape(roomID), url.PathEscape(eventID))
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := config.C.Platform.Matrix.AccessToken; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("error when requesting homeserver: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, xerrors.Errorf("error when reading event: %w", err)
+	}
+	matrix.RecordEvidence(body, "", "", resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		errResp := errorResponse{}
+		_ = json.Unmarshal(body, &errResp)
+		return nil, xerrors.Errorf("error when fetching event %s: status code %d %s %s", eventID, resp.StatusCode, errResp.ErrCode, errResp.Error)
+	}
+	ev := new(event)
+	if err := json.Unmarshal(body, ev); err != nil {
+		return nil, xerrors.Errorf("error when parsing event: %w", err)
+	}
+	matrix.Evidence.Author = ev.Sender
+	return ev, nil
+}
+
+func (matrix *Matrix) validateText() (err error) {
+	scanner := bufio.NewScanner(strings.NewReader(matrix.Text))
+	for scanner.Scan() {
+		matched := re.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if len(matched) < 2 {
+			continue // Search for next line
+		}
+
+		sigBase64 := matched[1]
+		sigBytes, err := util.DecodeString(sigBase64)
+		if err != nil {
+			return xerrors.Errorf("decoding signature %s: %s", sigBase64, err.Error())
+		}
+		matrix.Signature = sigBytes
+		return mycrypto.ValidatePersonalSignature(matrix.SignaturePayload, sigBytes, matrix.Pubkey)
+	}
+	return xerrors.Errorf("Signature not found in matrix event.")
+}
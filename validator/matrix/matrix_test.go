@@ -0,0 +1,166 @@
+package matrix
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	publicRoom = "!public:example.org"
+	ownRoom    = "!own:example.org"
+)
+
+func generate(pubkey *ecdsa.PublicKey, proofLocation string) Matrix {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return Matrix{
+		Base: &validator.Base{
+			Platform:      types.Platforms.Matrix,
+			Previous:      "",
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      "@Alice:example.org",
+			ProofLocation: proofLocation,
+			CreatedAt:     created_at,
+			Uuid:          uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+		},
+	}
+}
+
+// mockHomeserver serves given events, keyed by `roomID/eventID`.
+func mockHomeserver(t *testing.T, events map[string]event) func() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errcode":"M_MISSING_TOKEN","error":"Missing access token"}`))
+			return
+		}
+		key := strings.Replace(strings.TrimPrefix(r.URL.Path, "/_matrix/client/v3/rooms/"), "/event/", "/", 1)
+		ev, ok := events[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Event not found."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(ev)
+	}))
+	config.C.Platform.Matrix = config.MatrixPlatformConfig{
+		Homeserver:   ts.URL,
+		AccessToken:  "test-token",
+		PublicRoomID: publicRoom,
+	}
+	return func() {
+		ts.Close()
+		config.C.Platform.Matrix = config.MatrixPlatformConfig{}
+	}
+}
+
+func signedEvent(t *testing.T, matrix *Matrix, sk *ecdsa.PrivateKey, roomID, eventID, sender string) event {
+	sig, err := mycrypto.SignPersonal([]byte(matrix.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	ev := event{EventID: eventID, RoomID: roomID, Sender: sender, Type: "m.room.message"}
+	ev.Content.MsgType = "m.text"
+	ev.Content.Body = strings.ReplaceAll(matrix.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+	return ev
+}
+
+func Test_ParseProofLocation(t *testing.T) {
+	config.C.Platform.Matrix.PublicRoomID = publicRoom
+	defer func() { config.C.Platform.Matrix.PublicRoomID = "" }()
+
+	roomID, eventID, err := ParseProofLocation("$abc")
+	require.NoError(t, err)
+	require.Equal(t, publicRoom, roomID)
+	require.Equal(t, "$abc", eventID)
+
+	roomID, eventID, err = ParseProofLocation(ownRoom + "/$def")
+	require.NoError(t, err)
+	require.Equal(t, ownRoom, roomID)
+	require.Equal(t, "$def", eventID)
+
+	_, _, err = ParseProofLocation("abc")
+	require.Error(t, err)
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("success in public room", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, "$abc")
+		defer mockHomeserver(t, map[string]event{
+			publicRoom + "/$abc": signedEvent(t, &matrix, sk, publicRoom, "$abc", "@alice:example.org"),
+		})()
+
+		require.NoError(t, matrix.Validate())
+		require.Equal(t, "@alice:example.org", matrix.Identity)
+		require.Equal(t, matrix.Identity, matrix.AltID)
+		require.NotEmpty(t, matrix.Signature)
+	})
+
+	t.Run("success with state event in own room", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, ownRoom+"/$def")
+		ev := signedEvent(t, &matrix, sk, ownRoom, "$def", "@alice:example.org")
+		ev.Type, ev.StateKey = STATE_EVENT_TYPE, new(string)
+		defer mockHomeserver(t, map[string]event{ownRoom + "/$def": ev})()
+
+		require.NoError(t, matrix.Validate())
+	})
+
+	t.Run("message outside of public room", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, ownRoom+"/$def")
+		defer mockHomeserver(t, map[string]event{
+			ownRoom + "/$def": signedEvent(t, &matrix, sk, ownRoom, "$def", "@alice:example.org"),
+		})()
+
+		err := matrix.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not accepted outside of public room")
+	})
+
+	t.Run("sender mismatch", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, "$abc")
+		defer mockHomeserver(t, map[string]event{
+			publicRoom + "/$abc": signedEvent(t, &matrix, sk, publicRoom, "$abc", "@mallory:example.org"),
+		})()
+
+		err := matrix.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sender mismatch")
+	})
+
+	t.Run("event not found", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, "$missing")
+		defer mockHomeserver(t, map[string]event{})()
+
+		err := matrix.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "M_NOT_FOUND")
+	})
+
+	t.Run("signed by another persona", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		other, _ := mycrypto.GenerateSecp256k1Keypair()
+		matrix := generate(&sk.PublicKey, "$abc")
+		defer mockHomeserver(t, map[string]event{
+			publicRoom + "/$abc": signedEvent(t, &matrix, sk, publicRoom, "$abc", "@alice:example.org"),
+		})()
+
+		matrix.Pubkey = other
+		require.Error(t, matrix.Validate())
+	})
+}
@@ -129,13 +129,15 @@ func (minds *Minds) getContent() (post *MindsPayload, err error) {
 	if err != nil {
 		return nil, xerrors.Errorf("error when getting Minds post: %w", err)
 	}
-	if resp.StatusCode != 200 {
-		return nil, xerrors.Errorf("error when requesting proof: Status code %d", resp.StatusCode)
-	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, xerrors.Errorf("Error when getting resp body: %w", err)
 	}
+	minds.RecordEvidence(body, "", "", resp.StatusCode)
+	if resp.StatusCode != 200 {
+		return nil, xerrors.Errorf("error when requesting proof: Status code %d", resp.StatusCode)
+	}
 	post = new(MindsPayload)
 	err = json.Unmarshal(body, post)
 	if err != nil {
@@ -144,13 +146,20 @@ func (minds *Minds) getContent() (post *MindsPayload, err error) {
 	if len(post.Entities) == 0 {
 		return nil, xerrors.Errorf("Post not found")
 	}
+	minds.Evidence.Author = post.Entities[0].Owner.UserName
 	return post, nil
 }
 
 func (minds *Minds) validatePayload(payload *MindsPayload) error {
 	entity := payload.Entities[0]
-	if minds.Identity != strings.ToLower(entity.Owner.UserName) {
-		return xerrors.Errorf("Username mismatch: expect @%s, got @%s", minds.Identity, entity.Owner.UserName)
+	username := strings.ToLower(entity.Owner.UserName)
+	renamed := false
+	if minds.Identity != username {
+		// Known user (by GUID) with a new username.
+		if minds.AltID == "" || minds.AltID != entity.Owner.Guid {
+			return xerrors.Errorf("Username mismatch: expect @%s, got @%s", minds.Identity, entity.Owner.UserName)
+		}
+		renamed = true
 	}
 	minds.AltID = entity.Owner.Guid
 
@@ -166,7 +175,14 @@ func (minds *Minds) validatePayload(payload *MindsPayload) error {
 			return xerrors.Errorf("Error when decoding signature %s: %s", sigBase64, err.Error())
 		}
 		minds.Signature = sigBytes
-		return mycrypto.ValidatePersonalSignature(minds.SignaturePayload, sigBytes, minds.Pubkey)
+		if err := mycrypto.ValidatePersonalSignature(minds.SignaturePayload, sigBytes, minds.Pubkey); err != nil {
+			return err
+		}
+		if renamed {
+			l.Infof("user %s renamed from %s to %s", minds.AltID, minds.Identity, username)
+			minds.Identity = username
+		}
+		return nil
 	}
 
 	return xerrors.Errorf("Signature not found in post text.")
@@ -1,6 +1,7 @@
 package minds
 
 import (
+	"encoding/base64"
 	"strconv"
 	"testing"
 
@@ -74,3 +75,35 @@ func Test_Validate(t *testing.T) {
 		require.Equal(t, "1302892485034381316", minds.AltID)
 	})
 }
+
+func Test_validatePayload_renamed(t *testing.T) {
+	signedPayload := func(t *testing.T, minds *Minds, username, guid string) *MindsPayload {
+		_, sk := crypto.GenerateSecp256k1Keypair()
+		minds.Pubkey = &sk.PublicKey
+		minds.SignaturePayload = minds.GenerateSignPayload()
+		sig, err := crypto.SignPersonal([]byte(minds.SignaturePayload), sk)
+		require.NoError(t, err)
+		minds.Text = "Verifying my Minds ID\n\nSig: " + base64.StdEncoding.EncodeToString(sig)
+		return &MindsPayload{Entities: []MindsEntity{{
+			Message: minds.Text,
+			Owner:   MindsOwner{Guid: guid, UserName: username},
+		}}}
+	}
+
+	t.Run("known GUID", func(t *testing.T) {
+		minds := generate()
+		minds.AltID = "1302892485034381316"
+		payload := signedPayload(t, &minds, "Nykma_New", "1302892485034381316")
+
+		require.NoError(t, minds.validatePayload(payload))
+		require.Equal(t, "nykma_new", minds.Identity)
+	})
+
+	t.Run("unknown GUID", func(t *testing.T) {
+		minds := generate()
+		payload := signedPayload(t, &minds, "nykma_new", "1302892485034381316")
+
+		require.Error(t, minds.validatePayload(payload))
+		require.Equal(t, "nykma", minds.Identity)
+	})
+}
@@ -0,0 +1,22 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/nextdotid/proof_server/types"
+)
+
+// EXTRA_OWNER is the key in `Extra` of wallet address owning the
+// identity (lowercased hex). Recorded by `ens` validator if
+// `platform.ens.check_owner` is enabled.
+const EXTRA_OWNER = "ens_owner"
+
+// RecordedOwner gives owner wallet recorded in `extra` by validator of
+// `platform`, or empty string if none. `Extra` of other platforms is
+// given by user, thus never trusted.
+func RecordedOwner(platform types.Platform, extra map[string]string) string {
+	if platform != types.Platforms.ENS {
+		return ""
+	}
+	return strings.ToLower(extra[EXTRA_OWNER])
+}
@@ -0,0 +1,15 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/nextdotid/proof_server/types"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecordedOwner(t *testing.T) {
+	extra := map[string]string{EXTRA_OWNER: "0xABCD"}
+	require.Equal(t, "0xabcd", RecordedOwner(types.Platforms.ENS, extra))
+	require.Equal(t, "", RecordedOwner(types.Platforms.Twitter, extra))
+	require.Equal(t, "", RecordedOwner(types.Platforms.ENS, map[string]string{}))
+}
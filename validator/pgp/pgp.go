@@ -0,0 +1,233 @@
+package pgp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// PGP binds an OpenPGP key (identified by its primary key
+// fingerprint) to a persona. Proof is a clearsigned message, whose
+// cleartext is the sign payload followed by a `Sig:` line containing
+// persona signature.
+type PGP struct {
+	*validator.Base
+}
+
+const (
+	// DEFAULT_KEY_SERVER is used when `platform.pgp.key_server` is not set.
+	DEFAULT_KEY_SERVER = "https://keys.openpgp.org/vks/v1/by-fingerprint/%s"
+	POST_TEMPLATE      = "%s\nSig: %%SIG_BASE64%%\n"
+	MATCH_TEMPLATE     = "^Sig: (.+)$"
+
+	// EXTRA_SIGNED_MESSAGE is the `Extra` key of clearsigned message.
+	EXTRA_SIGNED_MESSAGE = "pgp_signed_message"
+	// EXTRA_PUBLIC_KEY is the `Extra` key of armored public key block.
+	EXTRA_PUBLIC_KEY = "pgp_public_key"
+)
+
+var (
+	l  = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "pgp"})
+	re = regexp.MustCompile(MATCH_TEMPLATE)
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.PGP] = func(base *validator.Base) validator.IValidator {
+		pgp := PGP{base}
+		return &pgp
+	}
+}
+
+func (pgp *PGP) GeneratePostPayload() (post map[string]string) {
+	return map[string]string{
+		"default": fmt.Sprintf(POST_TEMPLATE, pgp.GenerateSignPayload()),
+	}
+}
+
+func (pgp *PGP) GenerateSignPayload() (payload string) {
+	pgp.Identity = NormalizeFingerprint(pgp.Identity)
+	payloadStruct := validator.H{
+		"action":     string(pgp.Action),
+		"identity":   pgp.Identity,
+		"platform":   string(types.Platforms.PGP),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(pgp.CreatedAt),
+		"uuid":       pgp.Uuid.String(),
+	}
+	if pgp.Previous != "" {
+		payloadStruct["prev"] = pgp.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshaling struct: %s", err.Error())
+		return ""
+	}
+
+	return string(payloadBytes)
+}
+
+func (pgp *PGP) Validate() (err error) {
+	pgp.Identity = NormalizeFingerprint(pgp.Identity)
+	pgp.AltID = pgp.Identity
+	pgp.SignaturePayload = pgp.GenerateSignPayload()
+
+	// Deletion. No need to check PGP signature.
+	if pgp.Action == types.Actions.Delete {
+		return mycrypto.ValidatePersonalSignature(pgp.SignaturePayload, pgp.Signature, pgp.Pubkey)
+	}
+
+	message, err := pgp.signedMessage()
+	if err != nil {
+		return err
+	}
+	block, _ := clearsign.Decode([]byte(message))
+	if block == nil {
+		return xerrors.New("clearsigned message not found")
+	}
+
+	keyring, err := pgp.keyring()
+	if err != nil {
+		return err
+	}
+	if _, err = block.VerifySignature(keyring, nil); err != nil {
+		return xerrors.Errorf("PGP signature validation failed: %w", err)
+	}
+
+	pgp.Text = string(block.Plaintext)
+	if pgp.Extra == nil {
+		pgp.Extra = map[string]string{}
+	}
+	pgp.Extra[EXTRA_SIGNED_MESSAGE] = message
+	return pgp.validateText()
+}
+
+func (pgp *PGP) GetAltID() string {
+	return pgp.AltID
+}
+
+// NormalizeFingerprint converts user-given fingerprint into lowercased
+// hexstring without spaces or `0x` prefix.
+func NormalizeFingerprint(fingerprint string) string {
+	fingerprint = strings.ReplaceAll(strings.ToLower(fingerprint), " ", "")
+	return strings.TrimPrefix(fingerprint, "0x")
+}
+
+// signedMessage gives clearsigned message from `Extra`, or fetches it
+// from `ProofLocation`.
+func (pgp *PGP) signedMessage() (string, error) {
+	if message := pgp.Extra[EXTRA_SIGNED_MESSAGE]; message != "" {
+		return message, nil
+	}
+	if pgp.ProofLocation == "" {
+		return "", xerrors.Errorf("%s not found", EXTRA_SIGNED_MESSAGE)
+	}
+	message, statusCode, err := fetch(pgp.ProofLocation)
+	if statusCode != 0 {
+		pgp.RecordEvidence([]byte(message), "", "", statusCode)
+	}
+	if err != nil {
+		return "", xerrors.Errorf("error when fetching signed message: %w", err)
+	}
+	return message, nil
+}
+
+// keyring gives the entity of `Identity` only, parsed from the
+// public key block in `Extra` (offline) or the configured key server.
+func (pgp *PGP) keyring() (openpgp.EntityList, error) {
+	armored := pgp.Extra[EXTRA_PUBLIC_KEY]
+	if armored == "" {
+		keyServer := config.C.Platform.PGP.KeyServer
+		if keyServer == "" {
+			keyServer = DEFAULT_KEY_SERVER
+		}
+		var err error
+		armored, _, err = fetch(fmt.Sprintf(keyServer, strings.ToUpper(pgp.Identity)))
+		if err != nil {
+			return nil, xerrors.Errorf("error when fetching public key: %w", err)
+		}
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, xerrors.Errorf("error when parsing public key: %w", err)
+	}
+	for _, entity := range entities {
+		if hex.EncodeToString(entity.PrimaryKey.Fingerprint) == pgp.Identity {
+			return openpgp.EntityList{entity}, nil
+		}
+	}
+	return nil, xerrors.Errorf("public key %s not found in key block", pgp.Identity)
+}
+
+func (pgp *PGP) validateText() (err error) {
+	scanner := bufio.NewScanner(strings.NewReader(pgp.Text))
+	signPayloadFound := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == pgp.SignaturePayload {
+			signPayloadFound = true
+			continue
+		}
+		matched := re.FindStringSubmatch(line)
+		if len(matched) < 2 {
+			continue // Search for next line
+		}
+		if !signPayloadFound {
+			return xerrors.New("sign payload not found in signed message")
+		}
+
+		sigBytes, err := util.DecodeString(matched[1])
+		if err != nil {
+			return xerrors.Errorf("decoding signature %s: %s", matched[1], err.Error())
+		}
+		pgp.Signature = sigBytes
+		return mycrypto.ValidatePersonalSignature(pgp.SignaturePayload, sigBytes, pgp.Pubkey)
+	}
+	return xerrors.New("Signature not found in signed message.")
+}
+
+// fetch gives trimmed body and status code of response of `location`,
+// fetched by `validator.PublicClient`. Body is given along with error
+// of non-200 status code.
+func fetch(location string) (string, int, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", 0, xerrors.Errorf("error when parsing URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", 0, xerrors.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+	resp, err := validator.PublicClient.Get(u.String())
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, validator.PUBLIC_FETCH_LIMIT))
+	if err != nil {
+		return "", resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return string(body), resp.StatusCode, xerrors.Errorf("status code %d", resp.StatusCode)
+	}
+	return string(bytes.TrimSpace(body)), resp.StatusCode, nil
+}
@@ -0,0 +1,181 @@
+package pgp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntity(t *testing.T) (entity *openpgp.Entity, armoredPubkey string) {
+	entity, err := openpgp.NewEntity("Test", "", "test@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return entity, buf.String()
+}
+
+func generate(pubkey *ecdsa.PublicKey, entity *openpgp.Entity) PGP {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return PGP{
+		Base: &validator.Base{
+			Platform:  types.Platforms.PGP,
+			Previous:  "",
+			Action:    types.Actions.Create,
+			Pubkey:    pubkey,
+			Identity:  strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)),
+			CreatedAt: created_at,
+			Uuid:      uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+			Extra:     map[string]string{},
+		},
+	}
+}
+
+// sign fills persona signature into post payload, then clearsigns it with PGP key.
+func sign(t *testing.T, pgp *PGP, sk *ecdsa.PrivateKey, entity *openpgp.Entity) string {
+	sig, err := mycrypto.SignPersonal([]byte(pgp.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	post := strings.ReplaceAll(pgp.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+
+	buf := new(bytes.Buffer)
+	w, err := clearsign.Encode(buf, entity.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(post))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func Test_GeneratePostPayload(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		pk, _ := mycrypto.GenerateSecp256k1Keypair()
+		entity, _ := newEntity(t)
+		pgp := generate(pk, entity)
+		post := pgp.GeneratePostPayload()["default"]
+		require.Contains(t, post, pgp.GenerateSignPayload())
+		require.Contains(t, post, "Sig: %SIG_BASE64%")
+		require.Equal(t, strings.ToLower(pgp.Identity), pgp.Identity)
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("success offline", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_SIGNED_MESSAGE] = sign(t, &pgp, sk, entity)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = armored
+
+		require.NoError(t, pgp.Validate())
+		require.Equal(t, pgp.Identity, pgp.AltID)
+		require.NotEmpty(t, pgp.Signature)
+	})
+
+	t.Run("success with key server", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		pgp := generate(pk, entity)
+		message := sign(t, &pgp, sk, entity)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/by-fingerprint/" + strings.ToUpper(pgp.Identity):
+				w.Write([]byte(armored))
+			case "/proof.asc":
+				w.Write([]byte(message))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		// Mock server is on loopback.
+		original := validator.PublicClient
+		validator.PublicClient = http.DefaultClient
+		defer func() { validator.PublicClient = original }()
+		config.C.Platform.PGP.KeyServer = ts.URL + "/by-fingerprint/%s"
+		defer func() { config.C.Platform.PGP.KeyServer = "" }()
+		pgp.ProofLocation = ts.URL + "/proof.asc"
+
+		require.NoError(t, pgp.Validate())
+		require.Equal(t, message, pgp.Extra[EXTRA_SIGNED_MESSAGE])
+	})
+
+	t.Run("proof location of unsupported scheme", func(t *testing.T) {
+		pk, _ := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = armored
+		pgp.ProofLocation = "file:///etc/passwd"
+
+		err := pgp.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported URL scheme")
+	})
+
+	t.Run("proof location of private address", func(t *testing.T) {
+		pk, _ := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = armored
+		pgp.ProofLocation = "http://169.254.169.254/latest/meta-data/"
+
+		err := pgp.Validate()
+		require.ErrorIs(t, err, validator.ErrPrivateAddress)
+	})
+
+	t.Run("signed by another PGP key", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		other, _ := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_SIGNED_MESSAGE] = sign(t, &pgp, sk, other)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = armored
+
+		err := pgp.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "PGP signature validation failed")
+	})
+
+	t.Run("signed by another persona", func(t *testing.T) {
+		pk, _ := mycrypto.GenerateSecp256k1Keypair()
+		_, otherSk := mycrypto.GenerateSecp256k1Keypair()
+		entity, armored := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_SIGNED_MESSAGE] = sign(t, &pgp, otherSk, entity)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = armored
+
+		require.Error(t, pgp.Validate())
+	})
+
+	t.Run("fingerprint mismatch", func(t *testing.T) {
+		pk, sk := mycrypto.GenerateSecp256k1Keypair()
+		entity, _ := newEntity(t)
+		_, otherArmored := newEntity(t)
+		pgp := generate(pk, entity)
+		pgp.Extra[EXTRA_SIGNED_MESSAGE] = sign(t, &pgp, sk, entity)
+		pgp.Extra[EXTRA_PUBLIC_KEY] = otherArmored
+
+		err := pgp.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found in key block")
+	})
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/nextdotid/proof_server/headless"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// PUBLIC_FETCH_TIMEOUT limits each request of `PublicClient`.
+	PUBLIC_FETCH_TIMEOUT = 10 * time.Second
+	// PUBLIC_FETCH_LIMIT is the max body size read from URLs given by
+	// users.
+	PUBLIC_FETCH_LIMIT = 1 << 20
+)
+
+var (
+	ErrPrivateAddress = xerrors.New("private address is not allowed")
+
+	// PublicClient fetches URLs (or hosts) given by users. Connections
+	// to loopback, private and link-local addresses are refused, even
+	// if resolved from a public name or redirected to. Replaceable
+	// for testing.
+	PublicClient = newPublicClient()
+)
+
+func newPublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: PUBLIC_FETCH_TIMEOUT,
+		// Checked with address resolved, right before connecting.
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || headless.IsPrivateIP(ip) {
+				return xerrors.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Proxy would be connected instead of the host checked.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: PUBLIC_FETCH_TIMEOUT, Transport: transport}
+}
@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PublicClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer ts.Close()
+
+	_, err := newPublicClient().Get(ts.URL)
+	require.ErrorIs(t, err, ErrPrivateAddress)
+	_, err = newPublicClient().Get("http://169.254.169.254/latest/meta-data/")
+	require.ErrorIs(t, err, ErrPrivateAddress)
+}
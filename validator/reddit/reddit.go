@@ -0,0 +1,236 @@
+package reddit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// Reddit validates a Reddit account by a public post or comment.
+// `proof_location` is the permalink of it, whose `.json` rendering
+// is fetched.
+type Reddit struct {
+	*validator.Base
+}
+
+const (
+	// DEFAULT_BASE_URL is used when `platform.reddit.base_url` is not set.
+	DEFAULT_BASE_URL = "https://www.reddit.com"
+	MATCH_TEMPLATE   = "^Sig: (.+?)$"
+	USER_AGENT       = "proof_server/1.0 (+https://next.id)"
+)
+
+var (
+	l           = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "reddit"})
+	re          = regexp.MustCompile(MATCH_TEMPLATE)
+	POST_STRUCT = map[string]string{
+		"default": "🎭 Verify u/%s with @NextDotID.\n\nSig: %%SIG_BASE64%%\n\nMisc: %s|%s|%s",
+		"en_US":   "🎭 Verify u/%s with @NextDotID.\n\nSig: %%SIG_BASE64%%\n\nMisc: %s|%s|%s",
+		"zh_CN":   "🎭 由 @NextDotID 验证 u/%s 。\n\nSig: %%SIG_BASE64%%\n\n其它信息: %s|%s|%s",
+	}
+	// Permalink path: /r/SUBREDDIT/comments/POST_ID/SLUG/[COMMENT_ID/]
+	permalinkRe = regexp.MustCompile(`^/(?:r|user|u)/[^/]+/comments/([a-z0-9]+)(?:/[^/]*(?:/([a-z0-9]+))?)?/?$`)
+)
+
+// thing is a (partial) Reddit `Listing` child.
+// https://www.reddit.com/dev/api/#fullnames
+type thing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		ID             string `json:"id"`
+		Author         string `json:"author"`
+		AuthorFullname string `json:"author_fullname"`
+		Title          string `json:"title"`
+		Selftext       string `json:"selftext"`
+		Body           string `json:"body"`
+	} `json:"data"`
+}
+
+type listing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		Children []thing `json:"children"`
+	} `json:"data"`
+}
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.Reddit] = func(base *validator.Base) validator.IValidator {
+		reddit := Reddit{base}
+		return &reddit
+	}
+}
+
+func (reddit *Reddit) GeneratePostPayload() (post map[string]string) {
+	reddit.Identity = normalizeUsername(reddit.Identity)
+	post = make(map[string]string)
+	for langCode, template := range POST_STRUCT {
+		post[langCode] = fmt.Sprintf(template, reddit.Identity, reddit.Uuid.String(), util.TimeToTimestampString(reddit.CreatedAt), reddit.Previous)
+	}
+	return post
+}
+
+func (reddit *Reddit) GenerateSignPayload() (payload string) {
+	reddit.Identity = normalizeUsername(reddit.Identity)
+	payloadStruct := validator.H{
+		"action":     string(reddit.Action),
+		"identity":   reddit.Identity,
+		"platform":   string(types.Platforms.Reddit),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(reddit.CreatedAt),
+		"uuid":       reddit.Uuid.String(),
+	}
+	if reddit.Previous != "" {
+		payloadStruct["prev"] = reddit.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshaling struct: %s", err.Error())
+		return ""
+	}
+
+	return string(payloadBytes)
+}
+
+func (reddit *Reddit) Validate() (err error) {
+	reddit.Identity = normalizeUsername(reddit.Identity)
+	reddit.SignaturePayload = reddit.GenerateSignPayload()
+
+	// Deletion. No need to fetch the post.
+	if reddit.Action == types.Actions.Delete {
+		return mycrypto.ValidatePersonalSignature(reddit.SignaturePayload, reddit.Signature, reddit.Pubkey)
+	}
+
+	post, err := reddit.fetch()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(post.Data.Author, reddit.Identity) {
+		return xerrors.Errorf("author mismatch: expect %s, got %s", reddit.Identity, post.Data.Author)
+	}
+	reddit.AltID = post.Data.AuthorFullname
+
+	switch post.Kind {
+	case "t1": // Comment
+		reddit.Text = post.Data.Body
+	default: // Link (post)
+		reddit.Text = post.Data.Title + "\n" + post.Data.Selftext
+	}
+	return reddit.validateText()
+}
+
+func (reddit *Reddit) GetAltID() string {
+	return reddit.AltID
+}
+
+// PermalinkPath extracts the path of a post / comment permalink, with
+// its post ID and comment ID (empty if it links to a post).
+func PermalinkPath(permalink string) (path, postID, commentID string, err error) {
+	u, err := url.Parse(strings.TrimSpace(permalink))
+	if err != nil {
+		return "", "", "", xerrors.Errorf("error when parsing permalink: %w", err)
+	}
+	if u.Host != "" && u.Host != "reddit.com" && !strings.HasSuffix(u.Host, ".reddit.com") {
+		return "", "", "", xerrors.Errorf("not a reddit permalink: %s", permalink)
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(u.Path, ".json"), "/")
+	matched := permalinkRe.FindStringSubmatch(path)
+	if matched == nil {
+		return "", "", "", xerrors.Errorf("not a reddit permalink: %s", permalink)
+	}
+	return path, matched[1], matched[2], nil
+}
+
+// fetch gives the post / comment linked by `ProofLocation`.
+func (reddit *Reddit) fetch() (*thing, error) {
+	path, postID, commentID, err := PermalinkPath(reddit.ProofLocation)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := config.C.Platform.Reddit.BaseURL
+	if baseURL == "" {
+		baseURL = DEFAULT_BASE_URL
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(baseURL, "/")+path+"/.json?raw_json=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	// Reddit rejects requests with default Go UA.
+	req.Header.Set("User-Agent", USER_AGENT)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("error when requesting permalink: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	reddit.RecordEvidence(body, "", "", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("error when requesting permalink: status code %d", resp.StatusCode)
+	}
+
+	// [post listing, comments listing]
+	listings := []listing{}
+	if err := json.Unmarshal(body, &listings); err != nil {
+		return nil, xerrors.Errorf("error when parsing response: %w", err)
+	}
+
+	kind, id, index := "t3", postID, 0
+	if commentID != "" {
+		kind, id, index = "t1", commentID, 1
+	}
+	if len(listings) <= index {
+		return nil, xerrors.Errorf("%s_%s not found", kind, id)
+	}
+	for _, child := range listings[index].Data.Children {
+		if child.Kind == kind && child.Data.ID == id {
+			reddit.Evidence.Author = child.Data.Author
+			return &child, nil
+		}
+	}
+	return nil, xerrors.Errorf("%s_%s not found", kind, id)
+}
+
+func (reddit *Reddit) validateText() (err error) {
+	scanner := bufio.NewScanner(strings.NewReader(reddit.Text))
+	for scanner.Scan() {
+		matched := re.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if len(matched) < 2 {
+			continue // Search for next line
+		}
+
+		sigBase64 := matched[1]
+		sigBytes, err := util.DecodeString(sigBase64)
+		if err != nil {
+			return xerrors.Errorf("decoding signature %s: %s", sigBase64, err.Error())
+		}
+		reddit.Signature = sigBytes
+		return mycrypto.ValidatePersonalSignature(reddit.SignaturePayload, sigBytes, reddit.Pubkey)
+	}
+	return xerrors.Errorf("Signature not found in reddit post.")
+}
+
+// normalizeUsername lowercases username and strips `u/` prefix.
+func normalizeUsername(username string) string {
+	username = strings.ToLower(strings.TrimSpace(username))
+	username = strings.TrimPrefix(username, "/")
+	return strings.TrimPrefix(username, "u/")
+}
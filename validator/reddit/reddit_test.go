@@ -0,0 +1,142 @@
+package reddit
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func generate(pubkey *ecdsa.PublicKey, proofLocation string) Reddit {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return Reddit{
+		Base: &validator.Base{
+			Platform:      types.Platforms.Reddit,
+			Previous:      "",
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      "u/NextDotID",
+			ProofLocation: proofLocation,
+			CreatedAt:     created_at,
+			Uuid:          uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+		},
+	}
+}
+
+// mockServer serves a post `abc123` and its comment `def456`, both
+// containing persona-signed post payload and written by `author`.
+func mockServer(t *testing.T, author string, sk *ecdsa.PrivateKey) *httptest.Server {
+	reddit := generate(&sk.PublicKey, "")
+	sig, err := mycrypto.SignPersonal([]byte(reddit.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	text := strings.ReplaceAll(reddit.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+
+	post := thing{Kind: "t3"}
+	post.Data.ID, post.Data.Author, post.Data.AuthorFullname = "abc123", author, "t2_1w72"
+	post.Data.Title, post.Data.Selftext = "NextID proof", text
+	comment := thing{Kind: "t1"}
+	comment.Data.ID, comment.Data.Author, comment.Data.AuthorFullname = "def456", author, "t2_1w72"
+	comment.Data.Body = text
+
+	listings := make([]listing, 2)
+	listings[0].Data.Children = []thing{post}
+	listings[1].Data.Children = []thing{comment}
+	body, _ := json.Marshal(listings)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/r/NextDotID/comments/abc123/") || r.Header.Get("User-Agent") != USER_AGENT {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+func Test_PermalinkPath(t *testing.T) {
+	t.Run("post", func(t *testing.T) {
+		path, postID, commentID, err := PermalinkPath("https://www.reddit.com/r/NextDotID/comments/abc123/nextid_proof/")
+		require.NoError(t, err)
+		require.Equal(t, "/r/NextDotID/comments/abc123/nextid_proof", path)
+		require.Equal(t, "abc123", postID)
+		require.Empty(t, commentID)
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		_, postID, commentID, err := PermalinkPath("https://old.reddit.com/r/NextDotID/comments/abc123/nextid_proof/def456/")
+		require.NoError(t, err)
+		require.Equal(t, "abc123", postID)
+		require.Equal(t, "def456", commentID)
+	})
+
+	t.Run("other host", func(t *testing.T) {
+		_, _, _, err := PermalinkPath("https://evil.com/r/NextDotID/comments/abc123/nextid_proof/")
+		require.Error(t, err)
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("success with post", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "NextDotID", sk)
+		defer ts.Close()
+		config.C.Platform.Reddit.BaseURL = ts.URL
+		defer func() { config.C.Platform.Reddit.BaseURL = "" }()
+
+		reddit := generate(&sk.PublicKey, "https://www.reddit.com/r/NextDotID/comments/abc123/nextid_proof/")
+		require.NoError(t, reddit.Validate())
+		require.Equal(t, "nextdotid", reddit.Identity)
+		require.Equal(t, "t2_1w72", reddit.AltID)
+		require.NotEmpty(t, reddit.Signature)
+		require.Equal(t, "NextDotID", reddit.Evidence.Author)
+		require.Equal(t, http.StatusOK, reddit.Evidence.StatusCode)
+		require.NotEmpty(t, reddit.Evidence.Raw)
+	})
+
+	t.Run("success with comment", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "NextDotID", sk)
+		defer ts.Close()
+		config.C.Platform.Reddit.BaseURL = ts.URL
+		defer func() { config.C.Platform.Reddit.BaseURL = "" }()
+
+		reddit := generate(&sk.PublicKey, "/r/NextDotID/comments/abc123/nextid_proof/def456/")
+		require.NoError(t, reddit.Validate())
+		require.Equal(t, "t2_1w72", reddit.AltID)
+	})
+
+	t.Run("author mismatch", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "someone_else", sk)
+		defer ts.Close()
+		config.C.Platform.Reddit.BaseURL = ts.URL
+		defer func() { config.C.Platform.Reddit.BaseURL = "" }()
+
+		reddit := generate(&sk.PublicKey, "https://www.reddit.com/r/NextDotID/comments/abc123/nextid_proof/")
+		err := reddit.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "author mismatch")
+	})
+
+	t.Run("signed by another persona", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		other, _ := mycrypto.GenerateSecp256k1Keypair()
+		ts := mockServer(t, "NextDotID", sk)
+		defer ts.Close()
+		config.C.Platform.Reddit.BaseURL = ts.URL
+		defer func() { config.C.Platform.Reddit.BaseURL = "" }()
+
+		reddit := generate(other, "https://www.reddit.com/r/NextDotID/comments/abc123/nextid_proof/")
+		require.Error(t, reddit.Validate())
+	})
+}
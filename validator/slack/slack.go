@@ -2,8 +2,11 @@ package slack
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -114,6 +117,18 @@ func (slack *Slack) Validate() (err error) {
 	pageCount := 0
 	var foundMsg *slackClient.Message
 	var latestTs string
+	// Page containing the message is kept as evidence.
+	recorder := &validator.ResponseRecorder{Base: &validator.TokenTransport{Pool: tokenPool(), Authorize: authorize}}
+	historyClient := slackClient.New("", slackClient.OptionHTTPClient(&http.Client{Transport: recorder}))
+	defer func() {
+		if raw, statusCode := recorder.Last(); statusCode != 0 {
+			author := ""
+			if foundMsg != nil {
+				author = foundMsg.User
+			}
+			slack.RecordEvidence(raw, author, "", statusCode)
+		}
+	}()
 
 	for {
 		if pageCount >= maxPages {
@@ -121,7 +136,7 @@ func (slack *Slack) Validate() (err error) {
 		}
 
 		// Get conversation history
-		history, err := client.GetConversationHistory(&slackClient.GetConversationHistoryParameters{
+		history, err := historyClient.GetConversationHistory(&slackClient.GetConversationHistoryParameters{
 			ChannelID: channelID,
 			Latest:    latestTs,
 			Inclusive: true,
@@ -190,11 +205,37 @@ func (slack *Slack) validateText() (err error) {
 	return xerrors.Errorf("Signature not found in the slack message.")
 }
 
+// tokenPool gives the pool of API tokens configured. Clients are
+// created without token; it is set by `validator.TokenTransport`.
+func tokenPool() *validator.TokenPool {
+	slackConfig := config.C.Platform.Slack
+	return validator.GetTokenPool(types.Platforms.Slack, append([]string{slackConfig.ApiToken}, slackConfig.ApiTokens...))
+}
+
+// authorize sets `token` to header, and to `token` field of form body
+// which the client sends along.
+func authorize(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+	if req.Body == nil || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	values, parseErr := url.ParseQuery(string(body))
+	if err == nil && parseErr == nil {
+		values.Set("token", token)
+		body = []byte(values.Encode())
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+}
+
 func initClient() {
 	if client != nil {
 		return
 	}
-	client = slack.New(config.C.Platform.Slack.ApiToken)
+	client = slack.New("", slack.OptionHTTPClient(&http.Client{Transport: &validator.TokenTransport{Pool: tokenPool(), Authorize: authorize}}))
 	if _, err := client.AuthTest(); err != nil {
 		panic(fmt.Errorf("failed to authenticate the slack: %v", err))
 	}
@@ -1,6 +1,8 @@
 package slack
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/nextdotid/proof_server/util/crypto"
 	"github.com/nextdotid/proof_server/validator"
 	"github.com/sirupsen/logrus"
+	slackClient "github.com/slack-go/slack"
 	"github.com/stretchr/testify/require"
 )
 
@@ -74,3 +77,27 @@ func Test_Validate(t *testing.T) {
 		require.Equal(t, "U04Q3NRDWHX", slack.AltID)
 	})
 }
+
+func Test_authorize(t *testing.T) {
+	tokens := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "Bearer "+r.PostForm.Get("token"), r.Header.Get("Authorization"))
+		tokens = append(tokens, r.PostForm.Get("token"))
+		if r.PostForm.Get("token") == "xoxb-limited" {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"messages":[]}`))
+	}))
+	defer ts.Close()
+
+	pool := validator.NewTokenPool([]string{"xoxb-limited", "xoxb-available"})
+	client := slackClient.New("", slackClient.OptionAPIURL(ts.URL+"/"), slackClient.OptionHTTPClient(&http.Client{
+		Transport: &validator.TokenTransport{Pool: pool, Authorize: authorize},
+	}))
+	_, err := client.GetConversationHistory(&slackClient.GetConversationHistoryParameters{ChannelID: "C04Q3P6H7TK"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"xoxb-limited", "xoxb-available"}, tokens)
+}
@@ -0,0 +1,108 @@
+package sns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// HASH_PREFIX is prepended to name before hashing.
+	HASH_PREFIX = "SPL Name Service"
+	// NAME_HEADER_LENGTH is the length of name registry account header
+	// (parent name, owner, class). Record content follows it.
+	NAME_HEADER_LENGTH = 96
+
+	DEFAULT_RPC_SERVER = "https://api.mainnet-beta.solana.com"
+)
+
+var (
+	NAME_PROGRAM_ID = solana.MustPublicKeyFromBase58("namesLPneVptA9Z5rqUDD9tMTWEJwofgaYwp8cawRkX")
+	// ROOT_DOMAIN_ACCOUNT is the parent of all `.sol` domains.
+	ROOT_DOMAIN_ACCOUNT = solana.MustPublicKeyFromBase58("58PwtjSDuFHuUkYjH9BYnnQKHfwo9reZhC2zMJv9JPkx")
+)
+
+// NameRegistry is a name account of Solana Name Service.
+type NameRegistry struct {
+	ParentName solana.PublicKey
+	Owner      solana.PublicKey
+	Class      solana.PublicKey
+	// Data after header, with trailing zero padding.
+	Data []byte
+}
+
+// DomainKey gives account of `.sol` domain (or subdomain) `domain`.
+// https://github.com/SolanaNameService/sns-sdk (`getDomainKeySync`)
+func DomainKey(domain string) (solana.PublicKey, error) {
+	labels := strings.Split(strings.TrimSuffix(domain, ".sol"), ".")
+	parent := ROOT_DOMAIN_ACCOUNT
+	for i := len(labels) - 1; i >= 0; i-- {
+		if labels[i] == "" {
+			return solana.PublicKey{}, xerrors.Errorf("invalid domain: %s", domain)
+		}
+		name := labels[i]
+		if i != len(labels)-1 {
+			// Subdomain
+			name = "\x00" + name
+		}
+		key, err := nameAccountKey(name, parent)
+		if err != nil {
+			return solana.PublicKey{}, err
+		}
+		parent = key
+	}
+	return parent, nil
+}
+
+// RecordKey gives account of (v1) record `record` of `domain`, e.g. `TXT`.
+func RecordKey(domain, record string) (solana.PublicKey, error) {
+	domainKey, err := DomainKey(domain)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return nameAccountKey("\x01"+record, domainKey)
+}
+
+func nameAccountKey(name string, parent solana.PublicKey) (solana.PublicKey, error) {
+	hashed := sha256.Sum256([]byte(HASH_PREFIX + name))
+	// Class is not used by `.sol` domains.
+	key, _, err := solana.FindProgramAddress([][]byte{hashed[:], make([]byte, 32), parent[:]}, NAME_PROGRAM_ID)
+	if err != nil {
+		return solana.PublicKey{}, xerrors.Errorf("error when deriving name account: %w", err)
+	}
+	return key, nil
+}
+
+// GetNameRegistry reads name account `key` via Solana RPC.
+func GetNameRegistry(ctx context.Context, client *rpc.Client, key solana.PublicKey) (*NameRegistry, error) {
+	info, err := client.GetAccountInfo(ctx, key)
+	if err != nil {
+		if xerrors.Is(err, rpc.ErrNotFound) {
+			return nil, xerrors.Errorf("name account %s not found", key.String())
+		}
+		return nil, xerrors.Errorf("error when querying Solana RPC: %w", err)
+	}
+	if !info.Value.Owner.Equals(NAME_PROGRAM_ID) {
+		return nil, xerrors.Errorf("account %s is not a name account", key.String())
+	}
+	data := info.Value.Data.GetBinary()
+	if len(data) < NAME_HEADER_LENGTH {
+		return nil, xerrors.Errorf("name account %s too short", key.String())
+	}
+	return &NameRegistry{
+		ParentName: solana.PublicKeyFromBytes(data[0:32]),
+		Owner:      solana.PublicKeyFromBytes(data[32:64]),
+		Class:      solana.PublicKeyFromBytes(data[64:96]),
+		Data:       data[NAME_HEADER_LENGTH:],
+	}, nil
+}
+
+// Text gives record content as a string, with zero padding removed.
+func (registry *NameRegistry) Text() string {
+	return string(bytes.TrimRight(registry.Data, "\x00"))
+}
@@ -0,0 +1,198 @@
+package sns
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/mr-tron/base58"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/nextdotid/proof_server/validator/solana"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// SNS is a `.sol` domain of Solana Name Service.
+type SNS struct {
+	*validator.Base
+}
+
+const (
+	// RECORD is the record of domain to put persona signature in.
+	RECORD         = "TXT"
+	TXT_PAYLOAD_V1 = "ps:true;v:1;sig:%s;ca:%d;uuid:%s;prev:%s"
+)
+
+var (
+	l = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "sns"})
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.SNS] = func(base *validator.Base) validator.IValidator {
+		sns := SNS{base}
+		return &sns
+	}
+}
+
+// GeneratePostPayload gives content of `TXT` record. Not needed if
+// wallet signature is given in `extra`.
+func (sns *SNS) GeneratePostPayload() (post map[string]string) {
+	previous := "null"
+	if sns.Previous != "" {
+		previous = sns.Previous
+	}
+	return map[string]string{
+		"default": fmt.Sprintf(TXT_PAYLOAD_V1, "%SIG_BASE64%", sns.CreatedAt.Unix(), sns.Uuid.String(), previous),
+	}
+}
+
+func (sns *SNS) GenerateSignPayload() (payload string) {
+	payloadStruct := validator.H{
+		"action":     string(sns.Action),
+		"identity":   strings.ToLower(sns.Identity),
+		"persona":    "0x" + mycrypto.CompressedPubkeyHex(sns.Pubkey),
+		"platform":   string(types.Platforms.SNS),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(sns.CreatedAt),
+		"uuid":       sns.Uuid.String(),
+	}
+	if sns.Previous != "" {
+		payloadStruct["prev"] = sns.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshalling struct: %s", err.Error())
+		return ""
+	}
+	return string(payloadBytes)
+}
+
+// Validate accepts either a signature of domain owner wallet (base58,
+// in `extra.wallet_signature`), or a persona signature in `TXT` record
+// of the domain.
+func (sns *SNS) Validate() (err error) {
+	// domain name is case-insensitive
+	sns.Identity = strings.ToLower(strings.TrimSpace(sns.Identity))
+	if !strings.HasSuffix(sns.Identity, ".sol") {
+		return xerrors.Errorf("not a .sol domain: %s", sns.Identity)
+	}
+	sns.SignaturePayload = sns.GenerateSignPayload()
+	walletSig := sns.Extra["wallet_signature"]
+
+	switch sns.Action {
+	case types.Actions.Create:
+		if err := sns.resolveOwner(); err != nil {
+			return err
+		}
+		if walletSig != "" {
+			if err := solana.ValidateWalletSignature(sns.SignaturePayload, walletSig, sns.AltID); err != nil {
+				return xerrors.Errorf("invalid wallet signature: %w", err)
+			}
+			if err := mycrypto.ValidatePersonalSignature(sns.SignaturePayload, sns.Signature, sns.Pubkey); err != nil {
+				return xerrors.Errorf("invalid persona signature: %w", err)
+			}
+			return nil
+		}
+		return sns.validateRecord()
+	case types.Actions.Delete:
+		// Same as `solana`: signed by either owner wallet or persona.
+		if walletSig != "" {
+			if err := sns.resolveOwner(); err != nil {
+				return err
+			}
+			if err := solana.ValidateWalletSignature(sns.SignaturePayload, walletSig, sns.AltID); err != nil {
+				return xerrors.Errorf("invalid wallet signature: %w", err)
+			}
+			sns.Signature, err = base58.Decode(walletSig)
+			if err != nil {
+				return xerrors.Errorf("invalid wallet signature format: %w", err)
+			}
+			return nil
+		}
+		return mycrypto.ValidatePersonalSignature(sns.SignaturePayload, sns.Signature, sns.Pubkey)
+	default:
+		return xerrors.Errorf("unknown action: %s", sns.Action)
+	}
+}
+
+func (sns *SNS) GetAltID() string {
+	return sns.AltID
+}
+
+// resolveOwner saves owner wallet of the domain as `AltID`.
+func (sns *SNS) resolveOwner() error {
+	key, err := DomainKey(sns.Identity)
+	if err != nil {
+		return err
+	}
+	registry, err := GetNameRegistry(context.Background(), newClient(), key)
+	if err != nil {
+		return xerrors.Errorf("error when resolving owner of %s: %w", sns.Identity, err)
+	}
+	sns.AltID = registry.Owner.String()
+	return nil
+}
+
+// validateRecord checks signature in record of the domain. Must be
+// called after `resolveOwner`: records are kept after domain
+// transferred, so only ones written by current owner count.
+func (sns *SNS) validateRecord() error {
+	key, err := RecordKey(sns.Identity, RECORD)
+	if err != nil {
+		return err
+	}
+	registry, err := GetNameRegistry(context.Background(), newClient(), key)
+	if err != nil {
+		return xerrors.Errorf("%s record couldn't be retrieved: %w", RECORD, err)
+	}
+	if registry.Owner.String() != sns.AltID {
+		return xerrors.Errorf("%s record is owned by %s, not owner of domain %s", RECORD, registry.Owner.String(), sns.AltID)
+	}
+	sns.Text = registry.Text()
+	sig, err := parseRecord(sns.Text)
+	if err != nil {
+		return err
+	}
+	sns.Signature, err = base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return xerrors.New("sig in record cannot be recognized.")
+	}
+	return mycrypto.ValidatePersonalSignature(sns.SignaturePayload, sns.Signature, sns.Pubkey)
+}
+
+// parseRecord gives base64-encoded signature in record `content`.
+func parseRecord(content string) (sig string, err error) {
+	kv := make(map[string]string)
+	for _, field := range strings.Split(strings.Trim(strings.TrimSpace(content), "\""), ";") {
+		pair := strings.SplitN(field, ":", 2)
+		if len(pair) != 2 {
+			return "", xerrors.Errorf("record format error in %s", field)
+		}
+		kv[pair[0]] = pair[1]
+	}
+	if kv["ps"] != "true" || kv["v"] != "1" {
+		return "", xerrors.New("record payload not recognized")
+	}
+	if kv["sig"] == "" {
+		return "", xerrors.New("record payload not recognized: sig missing")
+	}
+	return kv["sig"], nil
+}
+
+func newClient() *rpc.Client {
+	endpoint := config.C.Platform.SNS.RPCServer
+	if endpoint == "" {
+		endpoint = DEFAULT_RPC_SERVER
+	}
+	return rpc.New(endpoint)
+}
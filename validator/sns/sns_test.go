@@ -0,0 +1,203 @@
+package sns
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRPC serves `getAccountInfo` of name accounts in `accounts`.
+func stubRPC(t *testing.T, accounts map[solana.PublicKey][]byte) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []json.RawMessage
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "getAccountInfo", req.Method)
+		var key solana.PublicKey
+		require.NoError(t, json.Unmarshal(req.Params[0], &key))
+
+		var value any
+		if data, ok := accounts[key]; ok {
+			value = map[string]any{
+				"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+				"executable": false,
+				"lamports":   1,
+				"owner":      NAME_PROGRAM_ID.String(),
+				"rentEpoch":  0,
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]any{"context": map[string]any{"slot": 1}, "value": value},
+		})
+	}))
+	t.Cleanup(ts.Close)
+	config.C.Platform.SNS.RPCServer = ts.URL
+	t.Cleanup(func() { config.C.Platform.SNS.RPCServer = "" })
+}
+
+func nameAccount(owner solana.PublicKey, content string) []byte {
+	data := make([]byte, NAME_HEADER_LENGTH, NAME_HEADER_LENGTH+len(content)+16)
+	copy(data[32:64], owner[:])
+	data = append(data, content...)
+	// Record accounts are allocated larger than content.
+	return append(data, make([]byte, 16)...)
+}
+
+func build(t *testing.T) (sns *SNS, persona func(payload string) []byte, wallet solana.PrivateKey) {
+	pk, sk := mycrypto.GenerateSecp256k1Keypair()
+	wallet, err := solana.NewRandomPrivateKey()
+	require.NoError(t, err)
+	sns = &SNS{&validator.Base{
+		Platform:  types.Platforms.SNS,
+		Action:    types.Actions.Create,
+		Pubkey:    pk,
+		Identity:  "Alice.sol",
+		Extra:     map[string]string{},
+		CreatedAt: time.Now(),
+		Uuid:      uuid.New(),
+	}}
+	return sns, func(payload string) []byte {
+		sig, err := mycrypto.SignPersonal([]byte(payload), sk)
+		require.NoError(t, err)
+		return sig
+	}, wallet
+}
+
+func Test_DomainKey(t *testing.T) {
+	key, err := DomainKey("bonfida.sol")
+	require.NoError(t, err)
+	require.Equal(t, "Crf8hzfthWGbGbLTVCiqRqV5MVnbpHB1L9KQMd6gsinb", key.String())
+
+	sub, err := DomainKey("dex.bonfida.sol")
+	require.NoError(t, err)
+	expected, err := nameAccountKey("\x00dex", key)
+	require.NoError(t, err)
+	require.Equal(t, expected, sub)
+
+	_, err = DomainKey("bad..sol")
+	require.Error(t, err)
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("wallet signature", func(t *testing.T) {
+		sns, persona, wallet := build(t)
+		payload := sns.GenerateSignPayload()
+		sns.Signature = persona(payload)
+		walletSig, err := wallet.Sign([]byte(payload))
+		require.NoError(t, err)
+		sns.Extra["wallet_signature"] = walletSig.String()
+
+		domainKey, _ := DomainKey("alice.sol")
+		stubRPC(t, map[solana.PublicKey][]byte{domainKey: nameAccount(wallet.PublicKey(), "")})
+
+		require.NoError(t, sns.Validate())
+		require.Equal(t, "alice.sol", sns.Identity)
+		require.Equal(t, wallet.PublicKey().String(), sns.GetAltID())
+	})
+
+	t.Run("wallet signature of non-owner", func(t *testing.T) {
+		sns, persona, wallet := build(t)
+		owner, _ := solana.NewRandomPrivateKey()
+		sns.Identity = "alice.sol"
+		sns.Signature = persona(sns.GenerateSignPayload())
+		walletSig, _ := wallet.Sign([]byte(sns.GenerateSignPayload()))
+		sns.Extra["wallet_signature"] = walletSig.String()
+
+		domainKey, _ := DomainKey("alice.sol")
+		stubRPC(t, map[solana.PublicKey][]byte{domainKey: nameAccount(owner.PublicKey(), "")})
+
+		err := sns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid wallet signature")
+	})
+
+	t.Run("TXT record", func(t *testing.T) {
+		sns, persona, wallet := build(t)
+		sns.Identity = "alice.sol"
+		record := strings.ReplaceAll(
+			sns.GeneratePostPayload()["default"],
+			"%SIG_BASE64%",
+			base64.StdEncoding.EncodeToString(persona(sns.GenerateSignPayload())),
+		)
+
+		domainKey, _ := DomainKey("alice.sol")
+		recordKey, _ := RecordKey("alice.sol", RECORD)
+		stubRPC(t, map[solana.PublicKey][]byte{
+			domainKey: nameAccount(wallet.PublicKey(), ""),
+			recordKey: nameAccount(wallet.PublicKey(), record),
+		})
+
+		require.NoError(t, sns.Validate())
+		require.Equal(t, record, sns.Text)
+		require.Equal(t, wallet.PublicKey().String(), sns.GetAltID())
+	})
+
+	t.Run("TXT record of previous owner", func(t *testing.T) {
+		sns, persona, wallet := build(t)
+		newOwner, _ := solana.NewRandomPrivateKey()
+		sns.Identity = "alice.sol"
+		record := strings.ReplaceAll(
+			sns.GeneratePostPayload()["default"],
+			"%SIG_BASE64%",
+			base64.StdEncoding.EncodeToString(persona(sns.GenerateSignPayload())),
+		)
+
+		// Domain transferred, with record left as-is.
+		domainKey, _ := DomainKey("alice.sol")
+		recordKey, _ := RecordKey("alice.sol", RECORD)
+		stubRPC(t, map[solana.PublicKey][]byte{
+			domainKey: nameAccount(newOwner.PublicKey(), ""),
+			recordKey: nameAccount(wallet.PublicKey(), record),
+		})
+
+		err := sns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not owner of domain")
+	})
+
+	t.Run("domain not registered", func(t *testing.T) {
+		sns, persona, _ := build(t)
+		sns.Identity = "alice.sol"
+		sns.Signature = persona(sns.GenerateSignPayload())
+		stubRPC(t, map[solana.PublicKey][]byte{})
+
+		err := sns.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("delete signed by persona", func(t *testing.T) {
+		sns, persona, _ := build(t)
+		sns.Identity = "alice.sol"
+		sns.Action = types.Actions.Delete
+		sns.Signature = persona(sns.GenerateSignPayload())
+
+		require.NoError(t, sns.Validate())
+	})
+}
+
+func Test_parseRecord(t *testing.T) {
+	sig, err := parseRecord("ps:true;v:1;sig:c2ln;ca:1;uuid:x;prev:null")
+	require.NoError(t, err)
+	require.Equal(t, "c2ln", sig)
+
+	_, err = parseRecord("hello")
+	require.Error(t, err)
+}
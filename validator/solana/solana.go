@@ -78,7 +78,7 @@ func (sol *Solana) validateCreate() (err error) {
 		return xerrors.Errorf("wallet_signature not found")
 	}
 
-	if err := validateWalletSignature(sol.SignaturePayload, walletSig, sol.Identity); err != nil {
+	if err := ValidateWalletSignature(sol.SignaturePayload, walletSig, sol.Identity); err != nil {
 		return xerrors.Errorf("invalid wallet signature %w", err)
 	}
 
@@ -98,7 +98,7 @@ func (sol *Solana) validateDelete() (err error) {
 
 	// If wallet_signature exists, check it
 	if ok && walletSig != "" {
-		err := validateWalletSignature(sol.SignaturePayload, walletSig, sol.Identity)
+		err := ValidateWalletSignature(sol.SignaturePayload, walletSig, sol.Identity)
 		if err != nil {
 			return xerrors.Errorf("invalid wallet signature %w", err)
 		}
@@ -119,7 +119,9 @@ func (sol *Solana) validateDelete() (err error) {
 	return nil
 }
 
-func validateWalletSignature(payload, sig, address string) error {
+// ValidateWalletSignature checks base58-encoded ed25519 `sig` of
+// `payload` against wallet `address`.
+func ValidateWalletSignature(payload, sig, address string) error {
 	pubkey, err := solana.PublicKeyFromBase58(address)
 	if err != nil {
 		return xerrors.Errorf("error when decoding pubkey: %w", err)
@@ -207,18 +207,21 @@ func (steam *Steam) GetUserInfo() (err error) {
 	if err != nil {
 		return xerrors.Errorf("getting steam profile page: %w", err)
 	}
-	if resp.StatusCode != 200 {
-		return xerrors.Errorf("getting steam profile page: status code %d", resp.StatusCode)
-	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return xerrors.Errorf("reading steam profile page body: %w", err)
 	}
+	steam.RecordEvidence(body, "", "", resp.StatusCode)
+	if resp.StatusCode != 200 {
+		return xerrors.Errorf("getting steam profile page: status code %d", resp.StatusCode)
+	}
 
 	uid, username, description, err := parseSteamXML(body)
 	if err != nil {
 		return err
 	}
+	steam.Evidence.Author = uid
 
 	steam.Identity = uid
 	steam.AltID = username
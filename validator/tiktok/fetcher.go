@@ -52,6 +52,10 @@ type OEmbedInfo struct {
 	EmbedType string `json:"embed_type"`
 	// embed_product_id : "7287329983805197614"
 	EmbedProductID string `json:"embed_product_id"`
+
+	// Raw is the response as fetched, and StatusCode of it.
+	Raw        []byte `json:"-"`
+	StatusCode int    `json:"-"`
 }
 
 type ErrorMessage struct {
@@ -61,7 +65,8 @@ type ErrorMessage struct {
 	Code    int    `json:"code"`
 }
 
-// fetchOembedInfo fetches OEmbed card info from TikTok.
+// fetchOembedInfo fetches OEmbed card info from TikTok. Along with
+// error, result may carry only the response telling so.
 // Sample: `https://www.tiktok.com/oembed?url=https://www.tiktok.com/@scout2015/video/6718335390845095173`
 func fetchOembedInfo(url string) (*OEmbedInfo, error) {
 	// FIXME: no need to marshal url manually, tiktok supports both full and shortened link.
@@ -75,19 +80,22 @@ func fetchOembedInfo(url string) (*OEmbedInfo, error) {
 	if err != nil {
 		return nil, xerrors.Errorf("tiktok: error when fetching oembed info: %w", err)
 	}
+	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, xerrors.Errorf("tiktok: error when reading oembed body: %w", err)
 	}
+	fetched := &OEmbedInfo{Raw: body, StatusCode: resp.StatusCode}
 
 	oembed := OEmbedInfo{}
 	if err = json.Unmarshal(body, &oembed); err != nil {
 		errorMessage := ErrorMessage{}
 		if err = json.Unmarshal(body, &errorMessage); err != nil {
-			return nil, xerrors.Errorf("tiktok: error when parsing oembed body: %w", err)
+			return fetched, xerrors.Errorf("tiktok: error when parsing oembed body: %w", err)
 		}
-		return nil, xerrors.Errorf("tiktok: fail to fetch video info: [%d] %s", errorMessage.Code, errorMessage.Message)
+		return fetched, xerrors.Errorf("tiktok: fail to fetch video info: [%d] %s", errorMessage.Code, errorMessage.Message)
 	}
+	oembed.Raw, oembed.StatusCode = fetched.Raw, fetched.StatusCode
 	return &oembed, nil
 }
 
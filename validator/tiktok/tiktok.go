@@ -76,6 +76,9 @@ func (tt *TikTok) GenerateSignPayload() (payload string) {
 
 func (tt *TikTok) Validate() (err error) {
 	oembedInfo, err := fetchOembedInfo(tt.ProofLocation)
+	if oembedInfo != nil {
+		tt.RecordEvidence(oembedInfo.Raw, oembedInfo.AuthorUniqueID, "", oembedInfo.StatusCode)
+	}
 	if err != nil {
 		return xerrors.Errorf("error when fetching tiktok proof: %w", err)
 	}
@@ -0,0 +1,289 @@
+package validator
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nextdotid/proof_server/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DEFAULT_PARK_DURATION is used when a token is rate limited but
+	// upstream does not tell when the limit resets.
+	DEFAULT_PARK_DURATION = time.Minute
+)
+
+var (
+	ErrNoTokenAvailable  = xerrors.New("all tokens are rate limited")
+	ErrNoTokenConfigured = xerrors.New("no token configured")
+
+	tokenPools   = map[types.Platform]*TokenPool{}
+	tokenPoolsMu sync.Mutex
+)
+
+// TokenPool rotates API credentials of one platform. Tokens exhausted
+// by rate limit are parked until the limit resets.
+type TokenPool struct {
+	mu     sync.Mutex
+	tokens []*pooledToken
+	next   int
+	now    func() time.Time
+}
+
+type pooledToken struct {
+	value       string
+	parkedUntil time.Time
+	// -1 if unknown.
+	remaining int
+	successes int64
+	failures  int64
+	lastError string
+}
+
+// TokenHealth is the status of one token in pool. Token itself is
+// masked.
+type TokenHealth struct {
+	Token       string    `json:"token"`
+	Available   bool      `json:"available"`
+	ParkedUntil time.Time `json:"parked_until,omitempty"`
+	Remaining   int       `json:"remaining"`
+	Successes   int64     `json:"successes"`
+	Failures    int64     `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// NewTokenPool skips empty and duplicated ones of `tokens`.
+func NewTokenPool(tokens []string) *TokenPool {
+	pool := &TokenPool{now: time.Now}
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		pool.tokens = append(pool.tokens, &pooledToken{value: token, remaining: -1})
+	}
+	return pool
+}
+
+// GetTokenPool gives the pool of `platform`, created with `tokens`
+// at first call.
+func GetTokenPool(platform types.Platform, tokens []string) *TokenPool {
+	tokenPoolsMu.Lock()
+	defer tokenPoolsMu.Unlock()
+	if pool, ok := tokenPools[platform]; ok {
+		return pool
+	}
+	pool := NewTokenPool(tokens)
+	tokenPools[platform] = pool
+	return pool
+}
+
+// TokenPoolHealth gives token status of all pools created.
+func TokenPoolHealth() map[types.Platform][]TokenHealth {
+	tokenPoolsMu.Lock()
+	defer tokenPoolsMu.Unlock()
+	result := make(map[types.Platform][]TokenHealth, len(tokenPools))
+	for platform, pool := range tokenPools {
+		result[platform] = pool.Health()
+	}
+	return result
+}
+
+func (pool *TokenPool) Len() int {
+	return len(pool.tokens)
+}
+
+// Acquire gives the next token not parked, round-robin.
+func (pool *TokenPool) Acquire() (string, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	now := pool.now()
+	for i := 0; i < len(pool.tokens); i++ {
+		token := pool.tokens[(pool.next+i)%len(pool.tokens)]
+		if now.Before(token.parkedUntil) {
+			continue
+		}
+		pool.next = (pool.next + i + 1) % len(pool.tokens)
+		return token.value, nil
+	}
+	return "", ErrNoTokenAvailable
+}
+
+// Report updates status of `token` with response of the request made
+// with it. Returns true if the token got rate limited by this request.
+func (pool *TokenPool) Report(token string, resp *http.Response, err error) (limited bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	var target *pooledToken
+	for _, t := range pool.tokens {
+		if t.value == token {
+			target = t
+		}
+	}
+	if target == nil {
+		return false
+	}
+	if err != nil {
+		target.failures++
+		target.lastError = err.Error()
+		return false
+	}
+
+	now := pool.now()
+	remaining, hasRemaining := rateLimitRemaining(resp.Header)
+	if hasRemaining {
+		target.remaining = remaining
+	}
+	limited = resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && hasRemaining && remaining == 0)
+	if limited || (hasRemaining && remaining == 0) {
+		resetAt, ok := rateLimitReset(resp.Header, now)
+		if !ok {
+			resetAt = now.Add(DEFAULT_PARK_DURATION)
+		}
+		target.parkedUntil = resetAt
+	}
+	if limited || resp.StatusCode >= 500 {
+		target.failures++
+		target.lastError = resp.Status
+	} else {
+		target.successes++
+	}
+	return limited
+}
+
+func (pool *TokenPool) Health() []TokenHealth {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	now := pool.now()
+	result := make([]TokenHealth, 0, len(pool.tokens))
+	for _, token := range pool.tokens {
+		health := TokenHealth{
+			Token:     maskToken(token.value),
+			Available: !now.Before(token.parkedUntil),
+			Remaining: token.remaining,
+			Successes: token.successes,
+			Failures:  token.failures,
+			LastError: token.lastError,
+		}
+		if !health.Available {
+			health.ParkedUntil = token.parkedUntil
+		}
+		result = append(result, health)
+	}
+	return result
+}
+
+// TokenTransport authenticates requests with tokens from `Pool`. A
+// rate-limited request is retried with the next token if possible.
+type TokenTransport struct {
+	Pool *TokenPool
+	// Authorize sets `token` to `req`. Defaults to bearer token.
+	Authorize func(req *http.Request, token string)
+	// Base defaults to `http.DefaultTransport`.
+	Base http.RoundTripper
+}
+
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	authorize := t.Authorize
+	if authorize == nil {
+		authorize = func(req *http.Request, token string) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	if t.Pool.Len() == 0 {
+		return nil, ErrNoTokenConfigured
+	}
+	var resp *http.Response
+	for attempt := 0; attempt < t.Pool.Len(); attempt++ {
+		token, err := t.Pool.Acquire()
+		if err != nil {
+			if resp != nil {
+				// Give the last rate-limited response to caller.
+				return resp, nil
+			}
+			return nil, err
+		}
+		outgoing := req.Clone(req.Context())
+		if req.Body != nil && req.GetBody != nil && attempt > 0 {
+			if outgoing.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		authorize(outgoing, token)
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = base.RoundTrip(outgoing)
+		limited := t.Pool.Report(token, resp, err)
+		if err != nil || !limited {
+			return resp, err
+		}
+		// Body could not be replayed.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+	}
+	if resp == nil {
+		return nil, ErrNoTokenAvailable
+	}
+	return resp, nil
+}
+
+// rateLimitRemaining reads `X-RateLimit-Remaining` (GitHub, Discord)
+// or `X-Rate-Limit-Remaining` (Twitter).
+func rateLimitRemaining(header http.Header) (int, bool) {
+	for _, key := range []string{"X-RateLimit-Remaining", "X-Rate-Limit-Remaining"} {
+		if value := header.Get(key); value != "" {
+			remaining, err := strconv.Atoi(value)
+			if err == nil {
+				return remaining, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// rateLimitReset tells when the limit resets, from `Retry-After`
+// (seconds or HTTP date), `X-RateLimit-Reset-After` (seconds) or
+// `X-RateLimit-Reset` / `X-Rate-Limit-Reset` (unix timestamp).
+func rateLimitReset(header http.Header, now time.Time) (time.Time, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return now.Add(time.Duration(seconds * float64(time.Second))), true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return date, true
+		}
+	}
+	if value := header.Get("X-RateLimit-Reset-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return now.Add(time.Duration(seconds * float64(time.Second))), true
+		}
+	}
+	for _, key := range []string{"X-RateLimit-Reset", "X-Rate-Limit-Reset"} {
+		if value := header.Get(key); value != "" {
+			if timestamp, err := strconv.ParseFloat(value, 64); err == nil {
+				return time.Unix(0, int64(timestamp*float64(time.Second))), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return "****"
+	}
+	return token[:4] + "****" + token[len(token)-4:]
+}
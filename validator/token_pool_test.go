@@ -0,0 +1,105 @@
+package validator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TokenPool_Acquire(t *testing.T) {
+	now := time.Now()
+	pool := NewTokenPool([]string{"ghp_aaaa1111", "", "ghp_bbbb2222", "ghp_aaaa1111"})
+	pool.now = func() time.Time { return now }
+	require.Equal(t, 2, pool.Len())
+
+	first, _ := pool.Acquire()
+	second, _ := pool.Acquire()
+	third, _ := pool.Acquire()
+	require.Equal(t, []string{"ghp_aaaa1111", "ghp_bbbb2222", "ghp_aaaa1111"}, []string{first, second, third})
+
+	resp := &http.Response{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+	require.True(t, pool.Report("ghp_aaaa1111", resp, nil))
+
+	for i := 0; i < 3; i++ {
+		token, err := pool.Acquire()
+		require.NoError(t, err)
+		require.Equal(t, "ghp_bbbb2222", token)
+	}
+
+	resp = &http.Response{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", Header: http.Header{}}
+	resp.Header.Set("Retry-After", "30")
+	require.True(t, pool.Report("ghp_bbbb2222", resp, nil))
+	_, err := pool.Acquire()
+	require.ErrorIs(t, err, ErrNoTokenAvailable)
+
+	health := pool.Health()
+	require.False(t, health[0].Available)
+	require.Equal(t, 0, health[0].Remaining)
+	require.Equal(t, "ghp_****1111", health[0].Token)
+	require.Equal(t, now.Add(30*time.Second), health[1].ParkedUntil)
+
+	now = now.Add(time.Minute)
+	token, err := pool.Acquire()
+	require.NoError(t, err)
+	require.Equal(t, "ghp_bbbb2222", token)
+}
+
+func Test_rateLimitReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for name, tc := range map[string]struct {
+		header   http.Header
+		expected time.Time
+	}{
+		"retry-after seconds": {http.Header{"Retry-After": {"5"}}, now.Add(5 * time.Second)},
+		"retry-after date":    {http.Header{"Retry-After": {now.Add(time.Hour).UTC().Format(http.TimeFormat)}}, now.Add(time.Hour)},
+		"discord reset-after": {http.Header{"X-Ratelimit-Reset-After": {"1.5"}}, now.Add(1500 * time.Millisecond)},
+		"twitter reset":       {http.Header{"X-Rate-Limit-Reset": {"1700000060"}}, now.Add(time.Minute)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resetAt, ok := rateLimitReset(tc.header, now)
+			require.True(t, ok)
+			require.True(t, tc.expected.Equal(resetAt), "expected %s, got %s", tc.expected, resetAt)
+		})
+	}
+
+	_, ok := rateLimitReset(http.Header{}, now)
+	require.False(t, ok)
+}
+
+func Test_TokenTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer exhausted" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	pool := NewTokenPool([]string{"exhausted", "fresh"})
+	client := &http.Client{Transport: &TokenTransport{Pool: pool}}
+
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	health := pool.Health()
+	require.False(t, health[0].Available)
+	require.Equal(t, int64(1), health[0].Failures)
+	require.True(t, health[1].Available)
+	require.Equal(t, 4999, health[1].Remaining)
+	require.Equal(t, int64(1), health[1].Successes)
+
+	client = &http.Client{Transport: &TokenTransport{Pool: NewTokenPool(nil)}}
+	_, err = client.Get(ts.URL)
+	require.ErrorIs(t, err, ErrNoTokenConfigured)
+}
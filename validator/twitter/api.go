@@ -2,12 +2,13 @@ package twitter
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"strings"
 
 	twitter "github.com/g8rswimmer/go-twitter/v2"
 	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/validator"
 	"golang.org/x/xerrors"
 )
 
@@ -17,18 +18,28 @@ type APIResponse struct {
 		ScreenName string `json:"screen_name"`
 	} `json:"user"`
 	Text string `json:"text"`
+	// Raw is the response as fetched, and StatusCode of it (0 if not
+	// fetched by HTTP). Kept as evidence.
+	Raw        []byte `json:"-"`
+	StatusCode int    `json:"-"`
+	// Headless is the tweet page, if fetched by headless browser.
+	Headless *validator.HeadlessPost `json:"-"`
 }
 
 var (
 	twitterClient *twitter.Client
 )
 
-type authorize struct {
-	Token string
-}
+// authorize does nothing: bearer tokens are set by
+// `validator.TokenTransport` from pool.
+type authorize struct{}
+
+func (a authorize) Add(req *http.Request) {}
 
-func (a authorize) Add(req *http.Request) {
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", a.Token))
+// tokenPool gives the pool of bearer tokens configured.
+func tokenPool() *validator.TokenPool {
+	twitterConfig := config.C.Platform.Twitter
+	return validator.GetTokenPool(types.Platforms.Twitter, append([]string{twitterConfig.OauthToken}, twitterConfig.OauthTokens...))
 }
 
 func initTwitterClient() {
@@ -36,11 +47,9 @@ func initTwitterClient() {
 		return
 	}
 	twitterClient = &twitter.Client{
-		Authorizer: authorize{
-			Token: config.C.Platform.Twitter.OauthToken,
-		},
-		Client: http.DefaultClient,
-		Host:   "https://api.twitter.com",
+		Authorizer: authorize{},
+		Client:     &http.Client{Transport: &validator.TokenTransport{Pool: tokenPool()}},
+		Host:       "https://api.twitter.com",
 	}
 }
 
@@ -48,21 +57,27 @@ func initTwitterClient() {
 // FIXME: should be switched to guest OAuth token solution.
 func fetchPostWithAPI(id string, maxRetries int) (*APIResponse, error) {
 	initTwitterClient()
+	recorder := &validator.ResponseRecorder{Base: twitterClient.Client.Transport}
+	client := *twitterClient
+	client.Client = &http.Client{Transport: recorder}
 	opts := twitter.TweetLookupOpts{
 		Expansions:  []twitter.Expansion{twitter.ExpansionEntitiesMentionsUserName, twitter.ExpansionAuthorID},
 		TweetFields: []twitter.TweetField{twitter.TweetFieldText, twitter.TweetFieldCreatedAt, twitter.TweetFieldEntities},
 	}
-	result, err := twitterClient.TweetLookup(context.Background(), []string{id}, opts)
+	result, err := client.TweetLookup(context.Background(), []string{id}, opts)
 	if err != nil {
 		return nil, xerrors.Errorf("error when retriving tweet: %w", err)
 	}
-	tweet := result.Raw.Tweets[0]
-	if tweet == nil {
-		return nil, xerrors.Errorf("tweet not found: %s", id)
+	raw, statusCode := recorder.Last()
+	if len(result.Raw.Tweets) == 0 || result.Raw.Tweets[0] == nil {
+		return &APIResponse{Raw: raw, StatusCode: statusCode}, xerrors.Errorf("%s: %w", id, ErrTweetNotFound)
 	}
+	tweet := result.Raw.Tweets[0]
 
 	response := APIResponse{
-		Text: tweet.Text,
+		Text:       tweet.Text,
+		Raw:        raw,
+		StatusCode: statusCode,
 	}
 	response.User.ID = tweet.AuthorID
 	userName, err := fetchUserName(tweet.AuthorID)
@@ -0,0 +1,51 @@
+package twitter
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BREAKER_THRESHOLD is the count of consecutive failures to open
+	// the breaker of a fetcher.
+	BREAKER_THRESHOLD = 5
+	// BREAKER_COOLDOWN is how long an opened breaker keeps the fetcher
+	// skipped. After it, one more failure opens the breaker again.
+	BREAKER_COOLDOWN = 5 * time.Minute
+)
+
+// circuitBreaker skips a fetcher which keeps failing, so that one
+// broken upstream does not slow down every validation.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{now: time.Now}
+}
+
+// Allow tells if the fetcher should be tried now.
+func (cb *circuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return !cb.now().Before(cb.openUntil)
+}
+
+func (cb *circuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.openUntil = time.Time{}
+}
+
+func (cb *circuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	if cb.failures >= BREAKER_THRESHOLD {
+		cb.openUntil = cb.now().Add(BREAKER_COOLDOWN)
+	}
+}
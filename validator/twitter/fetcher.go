@@ -0,0 +1,323 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/headless"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/nextdotid/proof_server/validator/activitypub"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// EXTRA_FETCHER is the `Extra` key of the fetcher which the
+	// validated tweet comes from.
+	EXTRA_FETCHER = "fetcher"
+
+	FETCHER_API         = "api"
+	FETCHER_SYNDICATION = "syndication"
+	FETCHER_OEMBED      = "oembed"
+	FETCHER_HEADLESS    = "headless"
+
+	// HEADLESS_EXTRACT_AUTHOR is the extraction giving author links.
+	HEADLESS_EXTRACT_AUTHOR = "author"
+)
+
+var (
+	// ErrTweetNotFound means the tweet is surely gone. Other fetchers
+	// will not be tried then.
+	ErrTweetNotFound = xerrors.New("tweet not found")
+	errNotConfigured = xerrors.New("fetcher not configured")
+
+	// Replaceable for testing.
+	syndicationEndpoint = "https://cdn.syndication.twimg.com/tweet-result"
+	oembedEndpoint      = "https://publish.twitter.com/oembed"
+	httpClient          = &http.Client{Timeout: 10 * time.Second}
+
+	reOEmbedParagraph = regexp.MustCompile(`(?s)<p[^>]*>(.*?)</p>`)
+	reDigits          = regexp.MustCompile(`^[0-9]+$`)
+
+	// Replaceable for testing.
+	getPostWithHeadless = validator.GetPostWithHeadlessBrowser
+
+	fetchers = []*fetcher{
+		{name: FETCHER_API, fetch: fetchWithAPI, breaker: newCircuitBreaker()},
+		{name: FETCHER_SYNDICATION, fetch: fetchWithSyndication, breaker: newCircuitBreaker()},
+		{name: FETCHER_OEMBED, fetch: fetchWithOEmbed, breaker: newCircuitBreaker()},
+		{name: FETCHER_HEADLESS, fetch: fetchWithHeadless, breaker: newCircuitBreaker()},
+	}
+)
+
+// fetcher gets a tweet in one way. `screenName` is needed by fetchers
+// which work with tweet URL. `User.ID` of result is empty if the
+// fetcher cannot tell it. Along with `ErrTweetNotFound`, result may
+// carry the response telling so.
+type fetcher struct {
+	name    string
+	fetch   func(screenName, id string) (*APIResponse, error)
+	breaker *circuitBreaker
+}
+
+// fetchTweet tries fetchers in order of `platform.twitter.fetchers`,
+// skipping those with opened breaker. Name of the fetcher which
+// succeeded (or found the tweet gone, with its response if any) is
+// returned.
+func fetchTweet(screenName, id string) (tweet *APIResponse, fetcherName string, err error) {
+	errs := []string{}
+	for _, f := range enabledFetchers() {
+		if !f.breaker.Allow() {
+			errs = append(errs, fmt.Sprintf("%s: circuit breaker open", f.name))
+			continue
+		}
+		tweet, err := f.fetch(screenName, id)
+		switch {
+		case err == nil:
+			f.breaker.Success()
+			return tweet, f.name, nil
+		case xerrors.Is(err, ErrTweetNotFound):
+			f.breaker.Success()
+			return tweet, f.name, xerrors.Errorf("%s: %w", f.name, err)
+		case xerrors.Is(err, errNotConfigured):
+			continue
+		}
+		l.Warnf("fetching tweet %s with %s: %s", id, f.name, err.Error())
+		f.breaker.Failure()
+		errs = append(errs, fmt.Sprintf("%s: %s", f.name, err.Error()))
+	}
+	return nil, "", xerrors.Errorf("all fetchers failed: %s", strings.Join(errs, "; "))
+}
+
+func enabledFetchers() []*fetcher {
+	names := config.C.Platform.Twitter.Fetchers
+	if len(names) == 0 {
+		return fetchers
+	}
+	result := make([]*fetcher, 0, len(names))
+	for _, name := range names {
+		for _, f := range fetchers {
+			if f.name == name {
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
+
+func fetchWithAPI(_, id string) (*APIResponse, error) {
+	if tokenPool().Len() == 0 {
+		return nil, errNotConfigured
+	}
+	return fetchPostWithAPI(id, 3)
+}
+
+type syndicationResponse struct {
+	TypeName string `json:"__typename"`
+	Text     string `json:"text"`
+	User     struct {
+		ID         string `json:"id_str"`
+		ScreenName string `json:"screen_name"`
+	} `json:"user"`
+}
+
+// fetchWithSyndication uses the endpoint behind embedded tweets.
+func fetchWithSyndication(_, id string) (*APIResponse, error) {
+	query := url.Values{"id": {id}, "lang": {"en"}, "token": {syndicationToken(id)}}
+	body, statusCode, err := getJSON(syndicationEndpoint + "?" + query.Encode())
+	if xerrors.Is(err, ErrTweetNotFound) {
+		return &APIResponse{Raw: body, StatusCode: statusCode}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := syndicationResponse{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, xerrors.Errorf("error when parsing syndication response: %w", err)
+	}
+	if resp.TypeName == "TweetTombstone" || resp.User.ScreenName == "" {
+		return &APIResponse{Raw: body, StatusCode: statusCode}, ErrTweetNotFound
+	}
+
+	tweet := &APIResponse{Text: resp.Text, Raw: body, StatusCode: statusCode}
+	tweet.User.ID = resp.User.ID
+	tweet.User.ScreenName = strings.ToLower(resp.User.ScreenName)
+	return tweet, nil
+}
+
+// syndicationToken is what embedded tweet widget sends along with
+// tweet ID, i.e. `(id / 1e15 * PI).toString(36)` without `0` and `.`.
+func syndicationToken(id string) string {
+	number, err := strconv.ParseFloat(id, 64)
+	if err != nil {
+		return ""
+	}
+	return strings.NewReplacer("0", "", ".", "").Replace(floatToRadix36(number / 1e15 * math.Pi))
+}
+
+// floatToRadix36 formats positive `value` the way JavaScript
+// `Number.prototype.toString(36)` does (V8 `DoubleToRadixCString`):
+// fraction digits are generated until `value` is distinguishable from
+// its neighbor doubles.
+func floatToRadix36(value float64) string {
+	const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	integer, fraction := math.Modf(value)
+	delta := math.Max(0.5*(math.Nextafter(value, math.Inf(1))-value), math.Nextafter(0, 1))
+	buffer := []byte{}
+	if fraction >= delta {
+		for {
+			fraction *= 36
+			delta *= 36
+			digit := int(fraction)
+			buffer = append(buffer, digits[digit])
+			fraction -= float64(digit)
+			if (fraction > 0.5 || (fraction == 0.5 && digit&1 == 1)) && fraction+delta > 1 {
+				// Round up, propagating carry.
+				for {
+					if len(buffer) == 0 {
+						integer++
+						break
+					}
+					last := strings.IndexByte(digits, buffer[len(buffer)-1])
+					buffer = buffer[:len(buffer)-1]
+					if last+1 < 36 {
+						buffer = append(buffer, digits[last+1])
+						break
+					}
+				}
+				break
+			}
+			if fraction < delta {
+				break
+			}
+		}
+	}
+	result := strconv.FormatInt(int64(integer), 36)
+	if len(buffer) > 0 {
+		result += "." + string(buffer)
+	}
+	return result
+}
+
+type oembedResponse struct {
+	AuthorURL string `json:"author_url"`
+	HTML      string `json:"html"`
+}
+
+// fetchWithOEmbed uses oEmbed of tweet URL. Tweet author ID is not
+// given in the result.
+func fetchWithOEmbed(screenName, id string) (*APIResponse, error) {
+	query := url.Values{
+		"url":         {fmt.Sprintf("https://twitter.com/%s/status/%s", screenName, id)},
+		"omit_script": {"true"},
+	}
+	body, statusCode, err := getJSON(oembedEndpoint + "?" + query.Encode())
+	if xerrors.Is(err, ErrTweetNotFound) {
+		return &APIResponse{Raw: body, StatusCode: statusCode}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := oembedResponse{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, xerrors.Errorf("error when parsing oembed response: %w", err)
+	}
+	authorURL, err := url.Parse(resp.AuthorURL)
+	if err != nil {
+		return nil, xerrors.Errorf("error when parsing author URL %s: %w", resp.AuthorURL, err)
+	}
+	paragraph := reOEmbedParagraph.FindStringSubmatch(resp.HTML)
+	if len(paragraph) < 2 {
+		return nil, xerrors.New("tweet text not found in oembed html")
+	}
+
+	tweet := &APIResponse{Text: activitypub.StripHTML(paragraph[1]), Raw: body, StatusCode: statusCode}
+	tweet.User.ScreenName = strings.ToLower(strings.Trim(authorURL.Path, "/"))
+	return tweet, nil
+}
+
+// fetchWithHeadless renders tweet page in headless browser. Tweet page
+// of another user redirects to the actual author, so the author is
+// told by timestamp link (`/<author>/status/<id>`) of the tweet in
+// page, never by the text. Tweet author ID is not given in the result.
+func fetchWithHeadless(screenName, id string) (*APIResponse, error) {
+	if len(config.C.Headless.Urls) == 0 {
+		return nil, errNotConfigured
+	}
+	if !reDigits.MatchString(id) {
+		return nil, xerrors.Errorf("invalid tweet ID: %s", id)
+	}
+	post, err := getPostWithHeadless(
+		context.Background(),
+		fmt.Sprintf("https://x.com/%s/status/%s", screenName, id),
+		HEADLESS_MATCH_TEMPLATE,
+		map[string]headless.Match{
+			HEADLESS_EXTRACT_AUTHOR: {
+				Type: "xpath",
+				MatchXPath: &headless.MatchXPath{
+					Selector: fmt.Sprintf(`//article//a[contains(@href, "/status/%s")][.//time]`, id),
+				},
+				All:       true,
+				Extract:   "attribute",
+				Attribute: "href",
+			},
+		},
+		config.C.Headless.Capture,
+	)
+	if err != nil {
+		return nil, err
+	}
+	author := headlessAuthor(post.Extracted[HEADLESS_EXTRACT_AUTHOR], id)
+	if author == "" {
+		return nil, xerrors.New("author of tweet not found in page")
+	}
+	// Page is rendered by headless service, so no status code here.
+	tweet := &APIResponse{Text: post.Text, Raw: []byte(post.Text), Headless: post}
+	tweet.User.ScreenName = author
+	return tweet, nil
+}
+
+// headlessAuthor gives author in the first link of `/<author>/status/<id>`.
+func headlessAuthor(hrefs []string, id string) string {
+	for _, href := range hrefs {
+		u, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(segments) == 3 && segments[1] == "status" && segments[2] == id && segments[0] != "" {
+			return strings.ToLower(segments[0])
+		}
+	}
+	return ""
+}
+
+// getJSON gives body and status code of response. Body of 404 is
+// given along with `ErrTweetNotFound`.
+func getJSON(url string) ([]byte, int, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, resp.StatusCode, xerrors.Errorf("status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, resp.StatusCode, xerrors.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return body, resp.StatusCode, ErrTweetNotFound
+	}
+	return body, resp.StatusCode, nil
+}
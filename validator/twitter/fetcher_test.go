@@ -0,0 +1,252 @@
+package twitter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/headless"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// useFetchers replaces fetcher chain with stubs named after their index.
+func useFetchers(t *testing.T, stubs ...func(screenName, id string) (*APIResponse, error)) []*fetcher {
+	original := fetchers
+	fetchers = make([]*fetcher, 0, len(stubs))
+	for i, stub := range stubs {
+		fetchers = append(fetchers, &fetcher{name: string(rune('a' + i)), fetch: stub, breaker: newCircuitBreaker()})
+	}
+	t.Cleanup(func() { fetchers = original })
+	return fetchers
+}
+
+func stubTweet(screenName string) *APIResponse {
+	tweet := &APIResponse{Text: "Sig: abc"}
+	tweet.User.ScreenName = screenName
+	return tweet
+}
+
+func failing(_, _ string) (*APIResponse, error) {
+	return nil, xerrors.New("upstream down")
+}
+
+func Test_fetchTweet(t *testing.T) {
+	t.Run("fallback to next fetcher", func(t *testing.T) {
+		useFetchers(t, failing, func(screenName, _ string) (*APIResponse, error) {
+			return stubTweet(screenName), nil
+		})
+		tweet, fetcherName, err := fetchTweet("yeiwb", "1")
+		require.NoError(t, err)
+		require.Equal(t, "b", fetcherName)
+		require.Equal(t, "yeiwb", tweet.User.ScreenName)
+	})
+
+	t.Run("not found stops the chain", func(t *testing.T) {
+		called := false
+		useFetchers(t, func(_, _ string) (*APIResponse, error) {
+			return nil, ErrTweetNotFound
+		}, func(screenName, _ string) (*APIResponse, error) {
+			called = true
+			return stubTweet(screenName), nil
+		})
+		_, _, err := fetchTweet("yeiwb", "1")
+		require.ErrorIs(t, err, ErrTweetNotFound)
+		require.False(t, called)
+	})
+
+	t.Run("unconfigured fetcher is skipped", func(t *testing.T) {
+		stubs := useFetchers(t, func(_, _ string) (*APIResponse, error) {
+			return nil, errNotConfigured
+		}, func(screenName, _ string) (*APIResponse, error) {
+			return stubTweet(screenName), nil
+		})
+		_, fetcherName, err := fetchTweet("yeiwb", "1")
+		require.NoError(t, err)
+		require.Equal(t, "b", fetcherName)
+		require.Equal(t, 0, stubs[0].breaker.failures)
+	})
+
+	t.Run("order from config", func(t *testing.T) {
+		useFetchers(t, failing, func(screenName, _ string) (*APIResponse, error) {
+			return stubTweet(screenName), nil
+		})
+		config.C.Platform.Twitter.Fetchers = []string{"a"}
+		defer func() { config.C.Platform.Twitter.Fetchers = nil }()
+
+		_, _, err := fetchTweet("yeiwb", "1")
+		require.Error(t, err)
+	})
+}
+
+func Test_circuitBreaker(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	stubs := useFetchers(t, func(_, _ string) (*APIResponse, error) {
+		calls++
+		return nil, xerrors.New("upstream down")
+	})
+	stubs[0].breaker.now = func() time.Time { return now }
+
+	for i := 0; i < BREAKER_THRESHOLD+2; i++ {
+		_, _, err := fetchTweet("yeiwb", "1")
+		require.Error(t, err)
+	}
+	require.Equal(t, BREAKER_THRESHOLD, calls, "should be skipped after breaker opened")
+
+	now = now.Add(BREAKER_COOLDOWN)
+	require.True(t, stubs[0].breaker.Allow())
+	stubs[0].breaker.Success()
+	require.Equal(t, 0, stubs[0].breaker.failures)
+}
+
+func Test_fetchWithSyndication(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id") {
+		case "1652176440396517378":
+			require.NotEmpty(t, r.URL.Query().Get("token"))
+			json.NewEncoder(w).Encode(map[string]any{
+				"__typename": "Tweet",
+				"text":       "Verify\nSig: abc",
+				"user":       map[string]any{"id_str": "292254624", "screen_name": "BGM38"},
+			})
+		case "2":
+			json.NewEncoder(w).Encode(map[string]any{"__typename": "TweetTombstone"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	original := syndicationEndpoint
+	syndicationEndpoint = ts.URL
+	defer func() { syndicationEndpoint = original }()
+
+	tweet, err := fetchWithSyndication("", "1652176440396517378")
+	require.NoError(t, err)
+	require.Equal(t, "bgm38", tweet.User.ScreenName)
+	require.Equal(t, "292254624", tweet.User.ID)
+	require.Equal(t, "Verify\nSig: abc", tweet.Text)
+	require.Equal(t, http.StatusOK, tweet.StatusCode)
+	require.Contains(t, string(tweet.Raw), `"screen_name":"BGM38"`)
+
+	tweet, err = fetchWithSyndication("", "2")
+	require.ErrorIs(t, err, ErrTweetNotFound)
+	require.Contains(t, string(tweet.Raw), "TweetTombstone")
+	tweet, err = fetchWithSyndication("", "3")
+	require.ErrorIs(t, err, ErrTweetNotFound)
+	require.Equal(t, http.StatusNotFound, tweet.StatusCode)
+}
+
+func Test_fetchWithOEmbed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "https://twitter.com/bgm38/status/1", r.URL.Query().Get("url"))
+		json.NewEncoder(w).Encode(map[string]any{
+			"author_url": "https://twitter.com/BGM38",
+			"html":       `<blockquote class="twitter-tweet"><p lang="en" dir="ltr">Verify &amp; go<br>Sig: abc</p>&mdash; BGM (@BGM38) <a href="https://twitter.com/BGM38/status/1">May 1, 2023</a></blockquote>`,
+		})
+	}))
+	defer ts.Close()
+	original := oembedEndpoint
+	oembedEndpoint = ts.URL
+	defer func() { oembedEndpoint = original }()
+
+	tweet, err := fetchWithOEmbed("bgm38", "1")
+	require.NoError(t, err)
+	require.Equal(t, "bgm38", tweet.User.ScreenName)
+	require.Empty(t, tweet.User.ID)
+	require.Equal(t, "Verify & go\nSig: abc", tweet.Text)
+}
+
+func Test_fetchWithHeadless(t *testing.T) {
+	originalUrls, original := config.C.Headless.Urls, getPostWithHeadless
+	config.C.Headless.Urls = []string{"http://headless"}
+	t.Cleanup(func() { config.C.Headless.Urls, getPostWithHeadless = originalUrls, original })
+	useHeadless := func(hrefs ...string) {
+		getPostWithHeadless = func(_ context.Context, _, _ string, extractions map[string]headless.Match, _ bool) (*validator.HeadlessPost, error) {
+			require.Contains(t, extractions, HEADLESS_EXTRACT_AUTHOR)
+			return &validator.HeadlessPost{
+				Text:      "@yeiwb Sig: abc",
+				Extracted: map[string][]string{HEADLESS_EXTRACT_AUTHOR: hrefs},
+			}, nil
+		}
+	}
+
+	t.Run("author from page", func(t *testing.T) {
+		useHeadless("/Attacker/status/123456")
+		tweet, err := fetchWithHeadless("yeiwb", "123456")
+		require.NoError(t, err)
+		require.Equal(t, "attacker", tweet.User.ScreenName, "mention in text is not trusted")
+		require.NotNil(t, tweet.Headless, "kept for evidence")
+	})
+
+	t.Run("author not found", func(t *testing.T) {
+		useHeadless("/yeiwb/status/1234567", "/yeiwb/status/123456/analytics")
+		_, err := fetchWithHeadless("yeiwb", "123456")
+		require.ErrorContains(t, err, "author of tweet not found")
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		useHeadless("/yeiwb/status/1")
+		_, err := fetchWithHeadless("yeiwb", `1"]|//a[@href`)
+		require.ErrorContains(t, err, "invalid tweet ID")
+	})
+}
+
+func Test_syndicationToken(t *testing.T) {
+	// Expected values are from JavaScript.
+	require.Equal(t, "406.gr46u88i", floatToRadix36(1652176440396517378/1e15*math.Pi))
+	require.Equal(t, "46gr46u88i", syndicationToken("1652176440396517378"))
+	require.Equal(t, "3na3ghomggp", syndicationToken("1504363098328924163"))
+	require.Equal(t, "6dq1a2xwd93", syndicationToken("20"))
+	require.Empty(t, syndicationToken("not a number"))
+}
+
+func Test_Validate_renamed(t *testing.T) {
+	signedTweet := func(t *testing.T, tw *Twitter, screenName, userID string) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		tw.Pubkey = &sk.PublicKey
+		sig, err := mycrypto.SignPersonal([]byte(tw.GenerateSignPayload()), sk)
+		require.NoError(t, err)
+		useFetchers(t, func(_, _ string) (*APIResponse, error) {
+			tweet := stubTweet(screenName)
+			tweet.User.ID = userID
+			tweet.Text = "Verifying my Twitter ID\nSig: " + base64.StdEncoding.EncodeToString(sig)
+			return tweet, nil
+		})
+	}
+
+	t.Run("known user ID", func(t *testing.T) {
+		tw := generate()
+		tw.AltID = "1468853291941773312"
+		signedTweet(t, &tw, "yeiwb_new", "1468853291941773312")
+
+		require.NoError(t, tw.Validate())
+		require.Equal(t, "yeiwb_new", tw.Identity)
+		require.Equal(t, "1468853291941773312", tw.AltID)
+	})
+
+	t.Run("unknown user ID", func(t *testing.T) {
+		tw := generate()
+		tw.AltID = "1468853291941773312"
+		signedTweet(t, &tw, "yeiwb_new", "42")
+
+		require.Error(t, tw.Validate())
+		require.Equal(t, "yeiwb", tw.Identity)
+	})
+
+	t.Run("fetcher without user ID", func(t *testing.T) {
+		tw := generate()
+		tw.AltID = "1468853291941773312"
+		signedTweet(t, &tw, "yeiwb_new", "")
+
+		require.Error(t, tw.Validate())
+	})
+}
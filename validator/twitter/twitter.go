@@ -97,24 +97,41 @@ func (twitter *Twitter) Validate() (err error) {
 		return xerrors.Errorf("parsing tweet ID %s: %s", twitter.ProofLocation, err.Error())
 	}
 
-	// post, err := validator.GetPostWithHeadlessBrowser(
-	// 	fmt.Sprintf("https://twitter.com/%s/status/%d", twitter.Identity, tweetID),
-	// 	"Sig:",
-	// )
-	// if err != nil {
-	// 	return xerrors.Errorf("fetching tweet with headless browser: %w", err)
-	// }
-
-	tweet, err := fetchPostWithAPI(fmt.Sprint(tweetID), 3)
+	tweet, fetcherName, err := fetchTweet(twitter.Identity, fmt.Sprint(tweetID))
+	if xerrors.Is(err, ErrTweetNotFound) && tweet != nil {
+		twitter.RecordEvidence(tweet.Raw, "", fetcherName, tweet.StatusCode)
+	}
 	if err != nil {
-		return xerrors.Errorf("fetching tweet with syndication API: %w", err)
+		return xerrors.Errorf("fetching tweet: %w", err)
 	}
+	twitter.RecordEvidence(tweet.Raw, tweet.User.ScreenName, fetcherName, tweet.StatusCode)
+	twitter.Evidence.AttachHeadless(tweet.Headless)
+	renamed := false
 	if twitter.Identity != tweet.User.ScreenName {
-		return xerrors.Errorf("tweet is not sent by this account.")
+		// Known user (by user ID) with a new screen name. Fetchers
+		// without user ID cannot tell this.
+		if twitter.AltID == "" || tweet.User.ID == "" || twitter.AltID != tweet.User.ID {
+			return xerrors.Errorf("tweet is not sent by this account.")
+		}
+		renamed = true
 	}
 	twitter.Text = tweet.Text
-	twitter.AltID = tweet.User.ID
-	return twitter.validateText()
+	// Not all fetchers give user ID. Keep the known one then.
+	if tweet.User.ID != "" {
+		twitter.AltID = tweet.User.ID
+	}
+	if twitter.Extra == nil {
+		twitter.Extra = map[string]string{}
+	}
+	twitter.Extra[EXTRA_FETCHER] = fetcherName
+	if err := twitter.validateText(); err != nil {
+		return err
+	}
+	if renamed {
+		l.Infof("user %s renamed from %s to %s", twitter.AltID, twitter.Identity, tweet.User.ScreenName)
+		twitter.Identity = tweet.User.ScreenName
+	}
+	return nil
 }
 
 func (twitter *Twitter) GetAltID() string {
@@ -0,0 +1,144 @@
+package youtube
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// API is the subset of YouTube Data API used by validator.
+type API interface {
+	// Channel finds a channel by its ID (`UC...`) or handle (`@handle`).
+	Channel(idOrHandle string) (*Channel, error)
+	// Video finds a video by its ID.
+	Video(id string) (*Video, error)
+}
+
+type Channel struct {
+	ID          string
+	Handle      string
+	Description string
+	// Raw is the API response as fetched, and StatusCode of it.
+	Raw        []byte
+	StatusCode int
+}
+
+type Video struct {
+	ID          string
+	ChannelID   string
+	Description string
+	// Raw is the API response as fetched, and StatusCode of it.
+	Raw        []byte
+	StatusCode int
+}
+
+// DataAPI calls YouTube Data API v3 with an API key.
+type DataAPI struct {
+	BaseURL string
+	Key     string
+	Client  *http.Client
+}
+
+// https://developers.google.com/youtube/v3/docs/channels/list
+// https://developers.google.com/youtube/v3/docs/videos/list
+type listResponse struct {
+	Items []struct {
+		ID      string `json:"id"`
+		Snippet struct {
+			ChannelID   string `json:"channelId"`
+			CustomURL   string `json:"customUrl"`
+			Description string `json:"description"`
+		} `json:"snippet"`
+	} `json:"items"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (api *DataAPI) Channel(idOrHandle string) (*Channel, error) {
+	query := url.Values{"part": {"snippet"}}
+	if isChannelID(idOrHandle) {
+		query.Set("id", idOrHandle)
+	} else {
+		query.Set("forHandle", idOrHandle)
+	}
+	resp, raw, statusCode, err := api.list("channels", query)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Items) == 0 {
+		return nil, xerrors.Errorf("channel %s not found", idOrHandle)
+	}
+	item := resp.Items[0]
+	return &Channel{
+		ID:          item.ID,
+		Handle:      item.Snippet.CustomURL,
+		Description: item.Snippet.Description,
+		Raw:         raw,
+		StatusCode:  statusCode,
+	}, nil
+}
+
+func (api *DataAPI) Video(id string) (*Video, error) {
+	resp, raw, statusCode, err := api.list("videos", url.Values{"part": {"snippet"}, "id": {id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Items) == 0 {
+		return nil, xerrors.Errorf("video %s not found", id)
+	}
+	item := resp.Items[0]
+	return &Video{
+		ID:          item.ID,
+		ChannelID:   item.Snippet.ChannelID,
+		Description: item.Snippet.Description,
+		Raw:         raw,
+		StatusCode:  statusCode,
+	}, nil
+}
+
+// list gives parsed response of `resource`, with its body and status
+// code.
+func (api *DataAPI) list(resource string, query url.Values) (*listResponse, []byte, int, error) {
+	if api.Key == "" {
+		return nil, nil, 0, xerrors.New("YouTube API key not configured")
+	}
+	client := api.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(api.BaseURL, "/")+"/"+resource+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, nil, 0, xerrors.Errorf("error when requesting YouTube API: %w", err)
+	}
+	// Not in query, so that it never shows in errors (with URL) given
+	// back to users.
+	req.Header.Set("X-Goog-Api-Key", api.Key)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, 0, xerrors.Errorf("error when requesting YouTube API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, resp.StatusCode, xerrors.Errorf("error when reading YouTube API response: %w", err)
+	}
+
+	result := new(listResponse)
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, body, resp.StatusCode, xerrors.Errorf("error when parsing YouTube API response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, body, resp.StatusCode, xerrors.Errorf("YouTube API error %d: %s", result.Error.Code, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, body, resp.StatusCode, xerrors.Errorf("YouTube API status code %d", resp.StatusCode)
+	}
+	return result, body, resp.StatusCode, nil
+}
@@ -0,0 +1,205 @@
+package youtube
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nextdotid/proof_server/config"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/xerrors"
+)
+
+// YouTube validates a YouTube channel by `Sig:` line in description
+// of the channel (About page), or of a video uploaded by the channel
+// (given as `proof_location`).
+type YouTube struct {
+	*validator.Base
+}
+
+const (
+	API_BASE       = "https://www.googleapis.com/youtube/v3"
+	MATCH_TEMPLATE = "^Sig: (.+?)$"
+)
+
+var (
+	l           = logrus.WithFields(logrus.Fields{"module": "validator", "validator": "youtube"})
+	re          = regexp.MustCompile(MATCH_TEMPLATE)
+	videoIDRe   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	channelIDRe = regexp.MustCompile(`^UC[A-Za-z0-9_-]{22}$`)
+	POST_STRUCT = map[string]string{
+		"default": "🎭 Verify YouTube channel %s with @NextDotID.\nSig: %%SIG_BASE64%%\nMisc: %s|%s|%s",
+		"en_US":   "🎭 Verify YouTube channel %s with @NextDotID.\nSig: %%SIG_BASE64%%\nMisc: %s|%s|%s",
+		"zh_CN":   "🎭 由 @NextDotID 验证 YouTube 频道 %s 。\nSig: %%SIG_BASE64%%\n其它信息: %s|%s|%s",
+	}
+
+	// NewAPI gives the YouTube API client used by validator.
+	// Replaceable for testing.
+	NewAPI = func() API {
+		return &DataAPI{BaseURL: API_BASE, Key: config.C.Platform.YouTube.APIKey}
+	}
+)
+
+func Init() {
+	if validator.PlatformFactories == nil {
+		validator.PlatformFactories = make(map[types.Platform]func(*validator.Base) validator.IValidator)
+	}
+	validator.PlatformFactories[types.Platforms.YouTube] = func(base *validator.Base) validator.IValidator {
+		yt := YouTube{base}
+		return &yt
+	}
+	validator.IdentityNormalizers[types.Platforms.YouTube] = func(identity string) []string {
+		return []string{NormalizeIdentity(identity)}
+	}
+}
+
+func (yt *YouTube) GeneratePostPayload() (post map[string]string) {
+	yt.Identity = NormalizeIdentity(yt.Identity)
+	post = make(map[string]string)
+	for langCode, template := range POST_STRUCT {
+		post[langCode] = fmt.Sprintf(template, yt.Identity, yt.Uuid.String(), util.TimeToTimestampString(yt.CreatedAt), yt.Previous)
+	}
+	return post
+}
+
+func (yt *YouTube) GenerateSignPayload() (payload string) {
+	yt.Identity = NormalizeIdentity(yt.Identity)
+	payloadStruct := validator.H{
+		"action":     string(yt.Action),
+		"identity":   yt.Identity,
+		"platform":   string(types.Platforms.YouTube),
+		"prev":       nil,
+		"created_at": util.TimeToTimestampString(yt.CreatedAt),
+		"uuid":       yt.Uuid.String(),
+	}
+	if yt.Previous != "" {
+		payloadStruct["prev"] = yt.Previous
+	}
+	payloadBytes, err := json.Marshal(payloadStruct)
+	if err != nil {
+		l.Warnf("Error when marshaling struct: %s", err.Error())
+		return ""
+	}
+
+	return string(payloadBytes)
+}
+
+func (yt *YouTube) Validate() (err error) {
+	yt.Identity = NormalizeIdentity(yt.Identity)
+	yt.SignaturePayload = yt.GenerateSignPayload()
+
+	// Deletion. No need to call YouTube API.
+	if yt.Action == types.Actions.Delete {
+		return mycrypto.ValidatePersonalSignature(yt.SignaturePayload, yt.Signature, yt.Pubkey)
+	}
+
+	api := NewAPI()
+	channel, err := api.Channel(yt.Identity)
+	if err != nil {
+		return xerrors.Errorf("error when finding channel: %w", err)
+	}
+	if isChannelID(yt.Identity) {
+		if channel.ID != yt.Identity {
+			return xerrors.Errorf("channel mismatch: expect %s, got %s", yt.Identity, channel.ID)
+		}
+	} else if !strings.EqualFold(channel.Handle, yt.Identity) {
+		return xerrors.Errorf("channel handle mismatch: expect %s, got %s", yt.Identity, channel.Handle)
+	}
+	yt.AltID = channel.ID
+
+	videoID, err := VideoID(yt.ProofLocation)
+	if err != nil {
+		return err
+	}
+	if videoID == "" {
+		yt.RecordEvidence(channel.Raw, channel.ID, "", channel.StatusCode)
+		yt.Text = channel.Description
+	} else {
+		video, err := api.Video(videoID)
+		if err != nil {
+			return xerrors.Errorf("error when finding video: %w", err)
+		}
+		yt.RecordEvidence(video.Raw, video.ChannelID, "", video.StatusCode)
+		if video.ChannelID != channel.ID {
+			return xerrors.Errorf("video %s is not uploaded by channel %s", videoID, channel.ID)
+		}
+		yt.Text = video.Description
+	}
+	return yt.validateText()
+}
+
+func (yt *YouTube) GetAltID() string {
+	return yt.AltID
+}
+
+// NormalizeIdentity keeps channel ID (`UC...`) as-is (it is case
+// sensitive), and converts handle into lowercased `@handle` form.
+func NormalizeIdentity(identity string) string {
+	identity = strings.TrimSpace(identity)
+	if isChannelID(identity) {
+		return identity
+	}
+	return "@" + strings.TrimPrefix(strings.ToLower(identity), "@")
+}
+
+// VideoID extracts video ID from `proof_location`, which could be a
+// video ID or a link of it. Empty location means channel description.
+func VideoID(location string) (string, error) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return "", nil
+	}
+	if videoIDRe.MatchString(location) {
+		return location, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", xerrors.Errorf("error when parsing proof location: %w", err)
+	}
+	id := ""
+	switch strings.TrimPrefix(u.Host, "www.") {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "m.youtube.com":
+		if u.Path == "/watch" {
+			id = u.Query().Get("v")
+		} else if strings.HasPrefix(u.Path, "/shorts/") {
+			id = strings.Trim(strings.TrimPrefix(u.Path, "/shorts/"), "/")
+		}
+	}
+	if !videoIDRe.MatchString(id) {
+		return "", xerrors.Errorf("video ID not found in proof location: %s", location)
+	}
+	return id, nil
+}
+
+func (yt *YouTube) validateText() (err error) {
+	scanner := bufio.NewScanner(strings.NewReader(yt.Text))
+	for scanner.Scan() {
+		matched := re.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if len(matched) < 2 {
+			continue // Search for next line
+		}
+
+		sigBase64 := matched[1]
+		sigBytes, err := util.DecodeString(sigBase64)
+		if err != nil {
+			return xerrors.Errorf("decoding signature %s: %s", sigBase64, err.Error())
+		}
+		yt.Signature = sigBytes
+		return mycrypto.ValidatePersonalSignature(yt.SignaturePayload, sigBytes, yt.Pubkey)
+	}
+	return xerrors.Errorf("Signature not found in description.")
+}
+
+func isChannelID(identity string) bool {
+	return channelIDRe.MatchString(identity)
+}
@@ -0,0 +1,194 @@
+package youtube
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nextdotid/proof_server/types"
+	"github.com/nextdotid/proof_server/util"
+	mycrypto "github.com/nextdotid/proof_server/util/crypto"
+	"github.com/nextdotid/proof_server/validator"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+const channelID = "UCxxxxxxxxxxxxxxxxxxxxxQ"
+
+// stubAPI serves one channel and its videos from memory.
+type stubAPI struct {
+	channel Channel
+	videos  map[string]Video
+}
+
+func (api *stubAPI) Channel(idOrHandle string) (*Channel, error) {
+	if idOrHandle != api.channel.ID && !strings.EqualFold(idOrHandle, api.channel.Handle) {
+		return nil, xerrors.Errorf("channel %s not found", idOrHandle)
+	}
+	return &api.channel, nil
+}
+
+func (api *stubAPI) Video(id string) (*Video, error) {
+	video, ok := api.videos[id]
+	if !ok {
+		return nil, xerrors.Errorf("video %s not found", id)
+	}
+	return &video, nil
+}
+
+func generate(pubkey *ecdsa.PublicKey, identity, proofLocation string) YouTube {
+	created_at, _ := util.TimestampStringToTime("1647329002")
+	return YouTube{
+		Base: &validator.Base{
+			Platform:      types.Platforms.YouTube,
+			Previous:      "",
+			Action:        types.Actions.Create,
+			Pubkey:        pubkey,
+			Identity:      identity,
+			ProofLocation: proofLocation,
+			CreatedAt:     created_at,
+			Uuid:          uuid.MustParse("909ee81f-4c5e-4319-affa-90d95eca614d"),
+		},
+	}
+}
+
+func signedText(t *testing.T, yt *YouTube, sk *ecdsa.PrivateKey) string {
+	sig, err := mycrypto.SignPersonal([]byte(yt.GenerateSignPayload()), sk)
+	require.NoError(t, err)
+	return "Welcome to my channel!\n\n" +
+		strings.ReplaceAll(yt.GeneratePostPayload()["default"], "%SIG_BASE64%", base64.StdEncoding.EncodeToString(sig))
+}
+
+func useStub(api *stubAPI) func() {
+	original := NewAPI
+	NewAPI = func() API { return api }
+	return func() { NewAPI = original }
+}
+
+func Test_NormalizeIdentity(t *testing.T) {
+	require.Equal(t, "@nextdotid", NormalizeIdentity(" NextDotID "))
+	require.Equal(t, "@nextdotid", NormalizeIdentity("@NextDotID"))
+	require.Equal(t, channelID, NormalizeIdentity(channelID))
+}
+
+func Test_VideoID(t *testing.T) {
+	for _, location := range []string{
+		"dQw4w9WgXcQ",
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42",
+		"https://youtu.be/dQw4w9WgXcQ",
+		"https://www.youtube.com/shorts/dQw4w9WgXcQ",
+	} {
+		id, err := VideoID(location)
+		require.NoError(t, err, location)
+		require.Equal(t, "dQw4w9WgXcQ", id, location)
+	}
+
+	id, err := VideoID("")
+	require.NoError(t, err)
+	require.Empty(t, id)
+
+	_, err = VideoID("https://example.com/watch?v=dQw4w9WgXcQ")
+	require.Error(t, err)
+}
+
+func Test_Validate(t *testing.T) {
+	t.Run("success with channel description", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		yt := generate(&sk.PublicKey, "@NextDotID", "")
+		defer useStub(&stubAPI{
+			channel: Channel{ID: channelID, Handle: "@nextdotid", Description: signedText(t, &yt, sk)},
+		})()
+
+		require.NoError(t, yt.Validate())
+		require.Equal(t, channelID, yt.AltID)
+		require.NotEmpty(t, yt.Signature)
+	})
+
+	t.Run("success with video description", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		yt := generate(&sk.PublicKey, channelID, "https://youtu.be/dQw4w9WgXcQ")
+		defer useStub(&stubAPI{
+			channel: Channel{ID: channelID, Handle: "@nextdotid"},
+			videos: map[string]Video{
+				"dQw4w9WgXcQ": {ID: "dQw4w9WgXcQ", ChannelID: channelID, Description: signedText(t, &yt, sk)},
+			},
+		})()
+
+		require.NoError(t, yt.Validate())
+		require.Equal(t, channelID, yt.AltID)
+	})
+
+	t.Run("video of another channel", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		yt := generate(&sk.PublicKey, "@nextdotid", "dQw4w9WgXcQ")
+		defer useStub(&stubAPI{
+			channel: Channel{ID: channelID, Handle: "@nextdotid"},
+			videos: map[string]Video{
+				"dQw4w9WgXcQ": {ID: "dQw4w9WgXcQ", ChannelID: "UCyyyyyyyyyyyyyyyyyyyyyQ", Description: signedText(t, &yt, sk)},
+			},
+		})()
+
+		err := yt.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not uploaded by channel")
+	})
+
+	t.Run("signed by another persona", func(t *testing.T) {
+		_, sk := mycrypto.GenerateSecp256k1Keypair()
+		other, _ := mycrypto.GenerateSecp256k1Keypair()
+		yt := generate(&sk.PublicKey, "@nextdotid", "")
+		defer useStub(&stubAPI{
+			channel: Channel{ID: channelID, Handle: "@nextdotid", Description: signedText(t, &yt, sk)},
+		})()
+
+		yt.Pubkey = other
+		require.Error(t, yt.Validate())
+	})
+}
+
+func Test_DataAPI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Header.Get("X-Goog-Api-Key") != "test-key" || query.Has("key"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":400,"message":"API key not valid."}}`))
+		case r.URL.Path == "/channels" && query.Get("forHandle") == "@nextdotid":
+			w.Write([]byte(`{"items":[{"id":"` + channelID + `","snippet":{"customUrl":"@nextdotid","description":"Sig: abc"}}]}`))
+		case r.URL.Path == "/videos" && query.Get("id") == "dQw4w9WgXcQ":
+			w.Write([]byte(`{"items":[{"id":"dQw4w9WgXcQ","snippet":{"channelId":"` + channelID + `","description":"Sig: def"}}]}`))
+		default:
+			w.Write([]byte(`{"items":[]}`))
+		}
+	}))
+	defer ts.Close()
+
+	api := &DataAPI{BaseURL: ts.URL, Key: "test-key"}
+	channel, err := api.Channel("@nextdotid")
+	require.NoError(t, err)
+	require.Equal(t, channelID, channel.ID)
+	require.Equal(t, "Sig: abc", channel.Description)
+	require.Equal(t, http.StatusOK, channel.StatusCode)
+	require.Contains(t, string(channel.Raw), `"customUrl":"@nextdotid"`)
+
+	video, err := api.Video("dQw4w9WgXcQ")
+	require.NoError(t, err)
+	require.Equal(t, channelID, video.ChannelID)
+
+	_, err = api.Channel(channelID)
+	require.Error(t, err)
+
+	api.Key = "wrong"
+	_, err = api.Video("dQw4w9WgXcQ")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "API key not valid")
+
+	api.BaseURL = "http://127.0.0.1:0"
+	_, err = api.Video("dQw4w9WgXcQ")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), api.Key, "key never shows in error")
+}